@@ -0,0 +1,107 @@
+package main
+
+import (
+	"chirpy/internal/auth"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	errAccountBanned    = errors.New("account is banned")
+	errAccountSuspended = errors.New("account is suspended")
+)
+
+// checkAccess returns an error if an account with the given restrictions may
+// not currently sign in or act.
+func checkAccess(bannedAt, suspendedUntil sql.NullTime) error {
+	if bannedAt.Valid {
+		return errAccountBanned
+	}
+	if suspendedUntil.Valid && suspendedUntil.Time.After(time.Now().UTC()) {
+		return errAccountSuspended
+	}
+	return nil
+}
+
+// authenticate validates the bearer JWT on the request and returns the ID of
+// the user it was issued to. The account is looked up on every request so
+// that deleting, suspending or banning it invalidates outstanding JWTs
+// immediately. On failure the error response has already been written and ok
+// is false.
+func (cfg *apiConfig) authenticate(w http.ResponseWriter, req *http.Request) (userID uuid.UUID, ok bool) {
+	headerToken, bearerErr := auth.GetBearerToken(req.Header)
+	if bearerErr != nil {
+		respondWithError(w, http.StatusUnauthorized, "Bad bearer", bearerErr)
+		return uuid.Nil, false
+	}
+
+	userID, tokenErr := auth.ValidateJWT(headerToken, cfg.secret)
+	if tokenErr != nil {
+		respondWithError(w, http.StatusUnauthorized, "Bad token", tokenErr)
+		return uuid.Nil, false
+	}
+
+	access, err := cfg.db.GetUserAccess(req.Context(), userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusUnauthorized, "Unknown user", err)
+		return uuid.Nil, false
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not check account", err)
+		return uuid.Nil, false
+	}
+	if err := checkAccess(access.BannedAt, access.SuspendedUntil); err != nil {
+		respondWithError(w, http.StatusForbidden, err.Error(), nil)
+		return uuid.Nil, false
+	}
+
+	return userID, true
+}
+
+// viewer returns the ID of the user making the request, or uuid.Nil for an
+// anonymous request. A request that sends a token must send a valid one. On
+// failure the error response has already been written and ok is false.
+func (cfg *apiConfig) viewer(w http.ResponseWriter, req *http.Request) (viewerID uuid.UUID, ok bool) {
+	if req.Header.Get("Authorization") == "" {
+		return uuid.Nil, true
+	}
+	return cfg.authenticate(w, req)
+}
+
+const (
+	roleUser      = "user"
+	roleModerator = "moderator"
+	roleAdmin     = "admin"
+)
+
+var roleRank = map[string]int{
+	roleUser:      0,
+	roleModerator: 1,
+	roleAdmin:     2,
+}
+
+// requireRole authenticates the request like authenticate and additionally
+// checks that the user holds at least the given role. Admins can do
+// everything moderators can.
+func (cfg *apiConfig) requireRole(w http.ResponseWriter, req *http.Request, role string) (userID uuid.UUID, ok bool) {
+	userID, ok = cfg.authenticate(w, req)
+	if !ok {
+		return uuid.Nil, false
+	}
+
+	userRole, err := cfg.db.GetUserRole(req.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Unknown user", err)
+		return uuid.Nil, false
+	}
+	if roleRank[userRole] < roleRank[role] {
+		respondWithError(w, http.StatusForbidden, "Insufficient permissions", nil)
+		return uuid.Nil, false
+	}
+
+	return userID, true
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"chirpy/internal/database"
+	"chirpy/internal/moderation"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	automodMaxLinks       = 3
+	automodMaxRepeats     = 10
+	automodRateWindow     = time.Minute
+	automodMaxRecentPosts = 10
+	automodNewAccountAge  = 24 * time.Hour
+)
+
+// newAutomod builds the pipeline every new chirp is screened with. patterns
+// is the regex deny-list.
+func newAutomod(patterns []*regexp.Regexp) moderation.Pipeline {
+	return moderation.Pipeline{Rules: []moderation.Rule{
+		moderation.LinkLimit{Max: automodMaxLinks},
+		moderation.RepeatedCharacters{Max: automodMaxRepeats},
+		moderation.PostingRate{Max: automodMaxRecentPosts},
+		moderation.NewAccount{MinAge: automodNewAccountAge},
+		moderation.DenyPatterns{Patterns: patterns},
+	}}
+}
+
+func loadDenyPatterns(path string) ([]*regexp.Regexp, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return moderation.LoadPatterns(file)
+}
+
+// automodRejection is returned by screenChirp when a rule rejects a chirp.
+// Its message names the rule and is only meant for the server log: telling
+// the author which rule matched would tell spammers what to avoid.
+type automodRejection struct {
+	Result moderation.Result
+}
+
+func (e *automodRejection) Error() string {
+	return fmt.Sprintf("automod rule %s rejected chirp: %s", e.Result.Rule, e.Result.Reason)
+}
+
+// screenChirp runs body, already validated, through the automated moderation
+// pipeline. It reports whether the chirp should be held for review, and
+// returns an *automodRejection if it must not be posted at all. Rejections
+// are recorded here; the caller records the results of a held chirp once it
+// has been written. When an existing chirp is edited, editing is its ID, so
+// it does not count against its own posting rate.
+func (cfg *apiConfig) screenChirp(ctx context.Context, userID uuid.UUID, editing uuid.NullUUID, body string) (held bool, results []moderation.Result, err error) {
+	user, err := cfg.db.GetUser(ctx, userID)
+	if err != nil {
+		return false, nil, err
+	}
+
+	now := time.Now().UTC()
+	recent, err := cfg.db.CountRecentChirps(ctx, database.CountRecentChirpsParams{
+		UserID:    userID,
+		Since:     now.Add(-automodRateWindow),
+		ExcludeID: editing,
+	})
+	if err != nil {
+		return false, nil, err
+	}
+
+	verdict, results := cfg.automod.Run(moderation.Post{
+		Body:        body,
+		AccountAge:  now.Sub(user.CreatedAt),
+		RecentPosts: int(recent),
+	})
+
+	switch verdict {
+	case moderation.Reject:
+		if err := saveAutomodResults(ctx, cfg.db, userID, uuid.NullUUID{}, body, results); err != nil {
+			return false, nil, err
+		}
+		for _, result := range results {
+			if result.Verdict == moderation.Reject {
+				return false, nil, &automodRejection{Result: result}
+			}
+		}
+	case moderation.Flag:
+		return true, results, nil
+	}
+	return false, nil, nil
+}
+
+func saveAutomodResults(ctx context.Context, q *database.Queries, userID uuid.UUID, chirpID uuid.NullUUID, body string, results []moderation.Result) error {
+	for _, result := range results {
+		err := q.CreateAutomodResult(ctx, database.CreateAutomodResultParams{
+			UserID:  userID,
+			ChirpID: chirpID,
+			Body:    body,
+			Rule:    result.Rule,
+			Verdict: result.Verdict.String(),
+			Reason:  result.Reason,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// get_held_chirps lists chirps held by automated moderation, most recently
+// held first, with the rules that held them.
+func (cfg *apiConfig) get_held_chirps(w http.ResponseWriter, req *http.Request) {
+	moderatorID, ok := cfg.requireRole(w, req, roleModerator)
+	if !ok {
+		return
+	}
+
+	type resultS struct {
+		Rule    string `json:"rule"`
+		Verdict string `json:"verdict"`
+		Reason  string `json:"reason"`
+	}
+
+	type heldChirp struct {
+		Chirp   Chirp     `json:"chirp"`
+		HeldAt  time.Time `json:"held_at"`
+		Results []resultS `json:"results"`
+	}
+
+	p, err := parsePage(req)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	chirps, err := cfg.db.GetHeldChirps(req.Context(), database.GetHeldChirpsParams{
+		Before:   p.Before,
+		BeforeID: p.BeforeID,
+		PageSize: p.Limit,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve chirps", err)
+		return
+	}
+
+	chirpIDs := make([]uuid.UUID, len(chirps))
+	for i, chirp := range chirps {
+		chirpIDs[i] = chirp.ID
+	}
+	rows, err := cfg.db.GetAutomodResults(req.Context(), chirpIDs)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve results", err)
+		return
+	}
+	results := make(map[uuid.UUID][]resultS)
+	for _, row := range rows {
+		results[row.ChirpID.UUID] = append(results[row.ChirpID.UUID], resultS{
+			Rule:    row.Rule,
+			Verdict: row.Verdict,
+			Reason:  row.Reason,
+		})
+	}
+
+	responseChirps, err := cfg.buildChirps(req.Context(), chirps, moderatorID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve chirps", err)
+		return
+	}
+
+	response := make([]heldChirp, len(chirps))
+	for i, chirp := range chirps {
+		response[i] = heldChirp{
+			Chirp:   responseChirps[i],
+			HeldAt:  chirp.HeldAt.Time,
+			Results: results[chirp.ID],
+		}
+		if response[i].Results == nil {
+			response[i].Results = []resultS{}
+		}
+	}
+	responseWithJSON(w, http.StatusOK, response)
+}
+
+// review_held_chirp approves or rejects a held chirp. Approving publishes it,
+// or leaves it to the publisher if it is scheduled, and indexes its entities,
+// which notifies mentioned users only now that the chirp is visible. A chirp
+// held after an edit is re-indexed the same way, without notifying users it
+// already mentioned. Rejecting hides it and tells the author.
+func (cfg *apiConfig) review_held_chirp(w http.ResponseWriter, req *http.Request) {
+	moderatorID, ok := cfg.requireRole(w, req, roleModerator)
+	if !ok {
+		return
+	}
+
+	type parameters struct {
+		Approve bool   `json:"approve"`
+		Note    string `json:"note"`
+	}
+
+	id, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Bad ID", err)
+		return
+	}
+
+	decoder := json.NewDecoder(req.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(req.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not review chirp", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	action := "approve_held"
+	var chirp database.Chirp
+	if params.Approve {
+		chirp, err = qtx.ReleaseHeldChirp(req.Context(), id)
+	} else {
+		action = "reject_held"
+		chirp, err = qtx.RejectHeldChirp(req.Context(), id)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "No held chirp with that ID", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not review chirp", err)
+		return
+	}
+
+	switch {
+	case params.Approve && chirp.ScheduledFor.Valid:
+		// The publisher indexes it when it is due.
+	case params.Approve:
+		err = replaceChirpEntities(req.Context(), qtx, chirp)
+	default:
+		err = notify(req.Context(), qtx, Notification{
+			UserID:  chirp.UserID,
+			Kind:    NotificationChirpModerated,
+			ChirpID: uuid.NullUUID{UUID: chirp.ID, Valid: true},
+			Data:    map[string]string{"action": "hidden", "reason": "automod"},
+		})
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not review chirp", err)
+		return
+	}
+
+	_, err = qtx.CreateModerationAction(req.Context(), database.CreateModerationActionParams{
+		ModeratorID: uuid.NullUUID{UUID: moderatorID, Valid: true},
+		Action:      action,
+		ChirpID:     uuid.NullUUID{UUID: chirp.ID, Valid: true},
+		UserID:      uuid.NullUUID{UUID: chirp.UserID, Valid: true},
+		Note:        params.Note,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not record action", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not review chirp", err)
+		return
+	}
+
+	responseWithJSON(w, http.StatusNoContent, nil)
+}
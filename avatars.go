@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"chirpy/internal/database"
+	"chirpy/internal/media"
+	"database/sql"
+	"net/http"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// avatarSizes are the square sizes, in pixels, every avatar is stored at,
+// smallest first.
+var avatarSizes = []int{48, 128, 400}
+
+var avatarFilePattern = regexp.MustCompile(`^[0-9a-f]{64}-(48|128|400)\.(png|jpg)$`)
+
+// avatarFile returns the storage key of one size of an avatar.
+func avatarFile(avatar string, size int) string {
+	ext := path.Ext(avatar)
+	return strings.TrimSuffix(avatar, ext) + "-" + strconv.Itoa(size) + ext
+}
+
+// avatarURLs returns the URL of each size of an avatar, keyed by size, or
+// nil if the user has none.
+func avatarURLs(avatar sql.NullString) map[string]string {
+	if !avatar.Valid {
+		return nil
+	}
+	urls := make(map[string]string, len(avatarSizes))
+	for _, size := range avatarSizes {
+		urls[strconv.Itoa(size)] = "/avatars/" + avatarFile(avatar.String, size)
+	}
+	return urls
+}
+
+// update_avatar accepts a PNG, JPEG or GIF image as the "file" field of a
+// multipart form, crops it to a centred square and stores it at every avatar
+// size. JPEG photos stay JPEG; everything else becomes PNG.
+func (cfg *apiConfig) update_avatar(w http.ResponseWriter, req *http.Request) {
+	userID, ok := cfg.authenticate(w, req)
+	if !ok {
+		return
+	}
+
+	type successS struct {
+		AvatarURLs map[string]string `json:"avatar_urls"`
+	}
+
+	file, ok := uploadedFile(w, req)
+	if !ok {
+		return
+	}
+	defer file.Close()
+
+	img, contentType, err := media.Decode(file, mediaLimits)
+	if err != nil {
+		respondWithMediaError(w, err)
+		return
+	}
+	if contentType != "image/jpeg" {
+		contentType = "image/png"
+	}
+
+	square := media.CropSquare(img)
+	resized := make([]media.Image, len(avatarSizes))
+	for i, size := range avatarSizes {
+		resized[i], err = media.Encode(media.Resize(square, size, size), contentType)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Could not resize avatar", err)
+			return
+		}
+	}
+
+	// Every size is named after the largest, so the set is content-addressed
+	// as a whole and a URL never changes what it points to.
+	avatar := media.Key(resized[len(resized)-1])
+	for i, size := range avatarSizes {
+		err := cfg.storage.Put(req.Context(), avatarFile(avatar, size), bytes.NewReader(resized[i].Data))
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Could not store avatar", err)
+			return
+		}
+	}
+
+	user, err := cfg.db.UpdateUserAvatar(req.Context(), database.UpdateUserAvatarParams{
+		Avatar: sql.NullString{String: avatar, Valid: true},
+		ID:     userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update user", err)
+		return
+	}
+
+	responseWithJSON(w, http.StatusOK, successS{AvatarURLs: avatarURLs(user.Avatar)})
+}
+
+// get_avatar serves one size of an avatar.
+func (cfg *apiConfig) get_avatar(w http.ResponseWriter, req *http.Request) {
+	file := req.PathValue("file")
+	if !avatarFilePattern.MatchString(file) {
+		respondWithError(w, http.StatusNotFound, "Not found", nil)
+		return
+	}
+
+	contentType := "image/png"
+	if path.Ext(file) == ".jpg" {
+		contentType = "image/jpeg"
+	}
+	cfg.serveStored(w, req, file, contentType, cacheImmutable)
+}
@@ -0,0 +1,439 @@
+package main
+
+import (
+	"chirpy/internal/database"
+	"chirpy/internal/entities"
+	"chirpy/internal/render"
+	"context"
+	"database/sql"
+	"errors"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Visibility levels of a chirp. Public chirps appear everywhere; unlisted
+// chirps are readable by anyone with the ID but left out of listings;
+// followers-only chirps are readable by the author's followers and private
+// chirps by the author alone.
+const (
+	visibilityPublic    = "public"
+	visibilityUnlisted  = "unlisted"
+	visibilityFollowers = "followers"
+	visibilityPrivate   = "private"
+)
+
+var visibilities = map[string]bool{
+	visibilityPublic:    true,
+	visibilityUnlisted:  true,
+	visibilityFollowers: true,
+	visibilityPrivate:   true,
+}
+
+// Chirp is the JSON representation of a chirp returned by the API. Collapsed
+// tells clients to hide a sensitive chirp behind its warning until the viewer
+// expands it; viewers who chose expand_sensitive get it false.
+type Chirp struct {
+	ID             uuid.UUID     `json:"id"`
+	CreatedAt      time.Time     `json:"created_at"`
+	UpdatedAt      time.Time     `json:"updated_at"`
+	Body           string        `json:"body"`
+	BodyHTML       string        `json:"body_html"`
+	UserID         uuid.UUID     `json:"user_id"`
+	Edited         bool          `json:"edited"`
+	EditedAt       *time.Time    `json:"edited_at,omitempty"`
+	Held           bool          `json:"held,omitempty"`
+	ContentWarning string        `json:"content_warning,omitempty"`
+	Sensitive      bool          `json:"sensitive"`
+	Collapsed      bool          `json:"collapsed"`
+	Visibility     string        `json:"visibility"`
+	Pinned         bool          `json:"pinned"`
+	ScheduledFor   *time.Time    `json:"scheduled_for,omitempty"`
+	ExpiresAt      *time.Time    `json:"expires_at,omitempty"`
+	Poll           *Poll         `json:"poll,omitempty"`
+	Media          []Media       `json:"media"`
+	Entities       ChirpEntities `json:"entities"`
+	RechirpOf      *Chirp        `json:"rechirp_of,omitempty"`
+	QuoteOf        *Chirp        `json:"quote_of,omitempty"`
+}
+
+// ChirpEntities lists the ranges of a chirp body that clients should render
+// as links. Offsets are in runes; End is exclusive.
+type ChirpEntities struct {
+	Hashtags []HashtagEntity `json:"hashtags"`
+	Mentions []MentionEntity `json:"mentions"`
+	URLs     []URLEntity     `json:"urls"`
+}
+
+type HashtagEntity struct {
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	Tag   string `json:"tag"`
+}
+
+type MentionEntity struct {
+	Start  int       `json:"start"`
+	End    int       `json:"end"`
+	UserID uuid.UUID `json:"user_id"`
+}
+
+// URLEntity is a link in a chirp body. ShortURL is left out for links that
+// were not shortened, and Preview until the page has been fetched.
+type URLEntity struct {
+	Start    int          `json:"start"`
+	End      int          `json:"end"`
+	URL      string       `json:"url"`
+	ShortURL string       `json:"short_url,omitempty"`
+	Preview  *LinkPreview `json:"preview,omitempty"`
+}
+
+// chirpFromDB converts a chirp row. links holds the shortened URLs.
+func chirpFromDB(chirp database.Chirp, mentions []database.ChirpMention, links map[string]chirpLink) Chirp {
+	response := Chirp{
+		ID:             chirp.ID,
+		CreatedAt:      chirp.CreatedAt,
+		UpdatedAt:      chirp.UpdatedAt,
+		Body:           chirp.Body,
+		UserID:         chirp.UserID,
+		Edited:         chirp.EditedAt.Valid,
+		Held:           chirp.HeldAt.Valid,
+		ContentWarning: chirp.ContentWarning.String,
+		Sensitive:      chirp.Sensitive,
+		Visibility:     chirp.Visibility,
+		Pinned:         chirp.PinnedAt.Valid,
+		Entities: ChirpEntities{
+			Hashtags: []HashtagEntity{},
+			Mentions: []MentionEntity{},
+			URLs:     []URLEntity{},
+		},
+		Media: []Media{},
+	}
+	if chirp.EditedAt.Valid {
+		response.EditedAt = &chirp.EditedAt.Time
+	}
+	if chirp.ScheduledFor.Valid {
+		response.ScheduledFor = &chirp.ScheduledFor.Time
+	}
+	if chirp.ExpiresAt.Valid {
+		response.ExpiresAt = &chirp.ExpiresAt.Time
+	}
+
+	for _, hashtag := range entities.Hashtags(chirp.Body) {
+		response.Entities.Hashtags = append(response.Entities.Hashtags, HashtagEntity{
+			Start: hashtag.Start,
+			End:   hashtag.End,
+			Tag:   hashtag.Tag,
+		})
+	}
+	for _, mention := range mentions {
+		response.Entities.Mentions = append(response.Entities.Mentions, MentionEntity{
+			Start:  int(mention.StartOffset),
+			End:    int(mention.EndOffset),
+			UserID: mention.UserID,
+		})
+	}
+	for _, link := range entities.URLs(chirp.Body) {
+		entity := URLEntity{Start: link.Start, End: link.End, URL: link.URL}
+		if short, ok := links[link.URL]; ok {
+			entity.ShortURL = linkURL(short.code)
+			entity.Preview = short.preview
+		}
+		response.Entities.URLs = append(response.Entities.URLs, entity)
+	}
+
+	response.BodyHTML = render.HTML(chirp.Body, append(linkedEntities(response.Entities), bodyEntities(chirp.Body)...))
+	return response
+}
+
+// linkedEntities returns the entities of a chirp that body_html links to.
+func linkedEntities(e ChirpEntities) []render.Entity {
+	var linked []render.Entity
+	for _, hashtag := range e.Hashtags {
+		linked = append(linked, render.Entity{
+			Start: hashtag.Start,
+			End:   hashtag.End,
+			Href:  "/api/hashtags/" + url.PathEscape(hashtag.Tag) + "/chirps",
+		})
+	}
+	for _, mention := range e.Mentions {
+		linked = append(linked, render.Entity{
+			Start: mention.Start,
+			End:   mention.End,
+			Href:  "/api/chirps?author_id=" + mention.UserID.String(),
+		})
+	}
+	for _, link := range e.URLs {
+		href := link.ShortURL
+		if href == "" {
+			href = link.URL
+		}
+		linked = append(linked, render.Entity{Start: link.Start, End: link.End, Href: href})
+	}
+	return linked
+}
+
+// bodyEntities returns every entity in body, without links, so formatting
+// characters inside handles, hashtags and URLs are never interpreted. It
+// includes mentions of handles that belong to nobody.
+func bodyEntities(body string) []render.Entity {
+	var ranges []render.Entity
+	for _, hashtag := range entities.Hashtags(body) {
+		ranges = append(ranges, render.Entity{Start: hashtag.Start, End: hashtag.End})
+	}
+	for _, mention := range entities.Mentions(body) {
+		ranges = append(ranges, render.Entity{Start: mention.Start, End: mention.End})
+	}
+	for _, link := range entities.URLs(body) {
+		ranges = append(ranges, render.Entity{Start: link.Start, End: link.End})
+	}
+	return ranges
+}
+
+// buildChirps converts database rows into API chirps as seen by viewerID,
+// embedding the original chirp of every rechirp and quote, polls and
+// attached images. Originals, mentions, links, polls and images are each
+// loaded in a constant number of queries, and originals are embedded one
+// level deep only. Sensitive chirps are collapsed unless the viewer prefers
+// them expanded.
+func (cfg *apiConfig) buildChirps(ctx context.Context, chirps []database.Chirp, viewerID uuid.UUID) ([]Chirp, error) {
+	var refIDs []uuid.UUID
+	for _, chirp := range chirps {
+		if chirp.RechirpOf.Valid {
+			refIDs = append(refIDs, chirp.RechirpOf.UUID)
+		}
+		if chirp.QuoteOf.Valid {
+			refIDs = append(refIDs, chirp.QuoteOf.UUID)
+		}
+	}
+
+	var originals []database.Chirp
+	if len(refIDs) > 0 {
+		var err error
+		originals, err = cfg.db.GetChirpsByIDs(ctx, refIDs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	chirpIDs := make([]uuid.UUID, 0, len(chirps)+len(originals))
+	authors := make(map[uuid.UUID]uuid.UUID, len(chirps)+len(originals))
+	for _, chirp := range chirps {
+		chirpIDs = append(chirpIDs, chirp.ID)
+		authors[chirp.ID] = chirp.UserID
+	}
+	for _, original := range originals {
+		chirpIDs = append(chirpIDs, original.ID)
+		authors[original.ID] = original.UserID
+	}
+
+	mentions := make(map[uuid.UUID][]database.ChirpMention)
+	if len(chirpIDs) > 0 {
+		rows, err := cfg.db.GetChirpMentions(ctx, chirpIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, mention := range rows {
+			mentions[mention.ChirpID] = append(mentions[mention.ChirpID], mention)
+		}
+	}
+
+	links, err := cfg.chirpLinks(ctx, append(originals, chirps...))
+	if err != nil {
+		return nil, err
+	}
+
+	polls := make(map[uuid.UUID]*Poll)
+	attachments := make(map[uuid.UUID][]Media)
+	if len(chirpIDs) > 0 {
+		var err error
+		polls, err = cfg.chirpPolls(ctx, authors, viewerID)
+		if err != nil {
+			return nil, err
+		}
+		attachments, err = cfg.chirpAttachments(ctx, chirpIDs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	collapse, err := cfg.collapsesSensitive(ctx, viewerID, append(originals, chirps...))
+	if err != nil {
+		return nil, err
+	}
+
+	refs := make(map[uuid.UUID]Chirp, len(originals))
+	for _, original := range originals {
+		ref := chirpFromDB(original, mentions[original.ID], links)
+		ref.Collapsed = collapse && isSensitive(original)
+		ref.Poll = polls[original.ID]
+		if media, ok := attachments[original.ID]; ok {
+			ref.Media = media
+		}
+		refs[original.ID] = ref
+	}
+
+	result := make([]Chirp, len(chirps))
+	for i, chirp := range chirps {
+		result[i] = chirpFromDB(chirp, mentions[chirp.ID], links)
+		result[i].Collapsed = collapse && isSensitive(chirp)
+		result[i].Poll = polls[chirp.ID]
+		if media, ok := attachments[chirp.ID]; ok {
+			result[i].Media = media
+		}
+		if original, ok := refs[chirp.RechirpOf.UUID]; ok && chirp.RechirpOf.Valid {
+			result[i].RechirpOf = &original
+		}
+		if original, ok := refs[chirp.QuoteOf.UUID]; ok && chirp.QuoteOf.Valid {
+			result[i].QuoteOf = &original
+		}
+	}
+	return result, nil
+}
+
+// collapsesSensitive reports whether sensitive chirps are collapsed for
+// viewerID, which they are unless the viewer has chosen to expand them. The
+// preference is only looked up when one of chirps is sensitive.
+func (cfg *apiConfig) collapsesSensitive(ctx context.Context, viewerID uuid.UUID, chirps []database.Chirp) (bool, error) {
+	if viewerID == uuid.Nil || !slices.ContainsFunc(chirps, isSensitive) {
+		return true, nil
+	}
+	expand, err := cfg.db.GetExpandSensitive(ctx, viewerID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return !expand, nil
+}
+
+func isSensitive(chirp database.Chirp) bool {
+	return chirp.Sensitive || chirp.ContentWarning.Valid
+}
+
+// visibleTo reports whether viewerID may read chirp. It mirrors the
+// visibility check in the GetChirp query for chirps already loaded.
+func visibleTo(ctx context.Context, q *database.Queries, chirp database.Chirp, viewerID uuid.UUID) (bool, error) {
+	switch {
+	case chirp.Visibility == visibilityPublic || chirp.Visibility == visibilityUnlisted:
+		return true, nil
+	case viewerID == chirp.UserID:
+		return true, nil
+	case chirp.Visibility == visibilityFollowers:
+		return q.IsFollowing(ctx, database.IsFollowingParams{FollowerID: viewerID, FolloweeID: chirp.UserID})
+	}
+	return false, nil
+}
+
+func (cfg *apiConfig) buildChirp(ctx context.Context, chirp database.Chirp, viewerID uuid.UUID) (Chirp, error) {
+	chirps, err := cfg.buildChirps(ctx, []database.Chirp{chirp}, viewerID)
+	if err != nil {
+		return Chirp{}, err
+	}
+	return chirps[0], nil
+}
+
+// saveChirpEntities indexes the hashtags, mentions and links in a newly
+// written chirp. It should run in the same transaction as the write.
+func saveChirpEntities(ctx context.Context, q *database.Queries, chirp database.Chirp) error {
+	if err := saveChirpHashtags(ctx, q, chirp); err != nil {
+		return err
+	}
+	if err := saveChirpLinks(ctx, q, chirp); err != nil {
+		return err
+	}
+	return saveChirpMentions(ctx, q, chirp, map[uuid.UUID]bool{})
+}
+
+// replaceChirpEntities re-indexes an edited chirp. Users who were already
+// mentioned before the edit are not notified again, and hashtags the chirp
+// already had keep the time they were first used, so editing a chirp does not
+// push its tags back up the trending list.
+func replaceChirpEntities(ctx context.Context, q *database.Queries, chirp database.Chirp) error {
+	previous, err := q.GetChirpMentions(ctx, []uuid.UUID{chirp.ID})
+	if err != nil {
+		return err
+	}
+	notified := make(map[uuid.UUID]bool, len(previous))
+	for _, mention := range previous {
+		notified[mention.UserID] = true
+	}
+
+	// An empty array rather than nil, which would be NULL and match nothing.
+	tags := append([]string{}, entities.UniqueTags(entities.Hashtags(chirp.Body))...)
+	err = q.DeleteStaleChirpHashtags(ctx, database.DeleteStaleChirpHashtagsParams{ChirpID: chirp.ID, Tags: tags})
+	if err != nil {
+		return err
+	}
+	if err := q.DeleteChirpMentions(ctx, chirp.ID); err != nil {
+		return err
+	}
+
+	if err := saveChirpHashtags(ctx, q, chirp); err != nil {
+		return err
+	}
+	if err := saveChirpLinks(ctx, q, chirp); err != nil {
+		return err
+	}
+	return saveChirpMentions(ctx, q, chirp, notified)
+}
+
+// saveChirpMentions resolves the @handles in the chirp body to users and
+// records each resolved mention with its offsets, notifying every mentioned
+// user other than the author who is not already in notified and who can read
+// the chirp. Handles that do not belong to anyone are left as plain text.
+func saveChirpMentions(ctx context.Context, q *database.Queries, chirp database.Chirp, notified map[uuid.UUID]bool) error {
+	mentions := entities.Mentions(chirp.Body)
+	if len(mentions) == 0 {
+		return nil
+	}
+
+	users, err := q.GetUsersByHandles(ctx, entities.UniqueHandles(mentions))
+	if err != nil {
+		return err
+	}
+	userIDs := make(map[string]uuid.UUID, len(users))
+	for _, user := range users {
+		userIDs[user.Handle.String] = user.ID
+	}
+
+	for _, mention := range mentions {
+		userID, ok := userIDs[mention.Handle]
+		if !ok {
+			continue
+		}
+		err := q.AddChirpMention(ctx, database.AddChirpMentionParams{
+			ChirpID:     chirp.ID,
+			UserID:      userID,
+			StartOffset: int32(mention.Start),
+			EndOffset:   int32(mention.End),
+		})
+		if err != nil {
+			return err
+		}
+
+		if userID == chirp.UserID || notified[userID] {
+			continue
+		}
+		visible, err := visibleTo(ctx, q, chirp, userID)
+		if err != nil {
+			return err
+		}
+		if !visible {
+			continue
+		}
+		notified[userID] = true
+		err = notify(ctx, q, Notification{
+			UserID:  userID,
+			Kind:    NotificationMention,
+			ActorID: uuid.NullUUID{UUID: chirp.UserID, Valid: true},
+			ChirpID: uuid.NullUUID{UUID: chirp.ID, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Expired chirps are already hidden by every read query, so sweeping only
+// reclaims space and can run much less often than chirps expire.
+const sweepInterval = 5 * time.Minute
+
+// sweepExpired permanently deletes ephemeral chirps that have expired, along
+// with their rechirps.
+func (cfg *apiConfig) sweepExpired(ctx context.Context) error {
+	return purgeInBatches(ctx, "expired chirps", func() (int64, error) {
+		return cfg.db.DeleteExpiredChirps(ctx, purgeBatchSize)
+	})
+}
+
+// runSweeper sweeps expired chirps every sweepInterval until ctx is
+// cancelled.
+func (cfg *apiConfig) runSweeper(ctx context.Context) {
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := cfg.sweepExpired(ctx); err != nil {
+			log.Printf("Could not sweep expired chirps: %s", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"chirpy/internal/database"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func (cfg *apiConfig) bookmark_chirp(w http.ResponseWriter, req *http.Request) {
+	userID, ok := cfg.authenticate(w, req)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Bad ID", err)
+		return
+	}
+
+	chirp, err := cfg.db.GetChirp(req.Context(), database.GetChirpParams{ID: id, ViewerID: userID})
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Not found", err)
+		return
+	}
+
+	err = cfg.db.CreateBookmark(req.Context(), database.CreateBookmarkParams{UserID: userID, ChirpID: chirp.ID})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not bookmark chirp", err)
+		return
+	}
+
+	responseWithJSON(w, http.StatusNoContent, nil)
+}
+
+func (cfg *apiConfig) unbookmark_chirp(w http.ResponseWriter, req *http.Request) {
+	userID, ok := cfg.authenticate(w, req)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Bad ID", err)
+		return
+	}
+
+	err = cfg.db.DeleteBookmark(req.Context(), database.DeleteBookmarkParams{UserID: userID, ChirpID: id})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not remove bookmark", err)
+		return
+	}
+
+	responseWithJSON(w, http.StatusNoContent, nil)
+}
+
+func (cfg *apiConfig) get_bookmarks(w http.ResponseWriter, req *http.Request) {
+	userID, ok := cfg.authenticate(w, req)
+	if !ok {
+		return
+	}
+
+	type bookmarkS struct {
+		BookmarkedAt time.Time `json:"bookmarked_at"`
+		Chirp        Chirp     `json:"chirp"`
+	}
+
+	p, err := parsePage(req)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	hide, err := hideSensitive(req)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	rows, err := cfg.db.GetBookmarkedChirps(req.Context(), database.GetBookmarkedChirpsParams{
+		UserID:        userID,
+		Before:        p.Before,
+		BeforeID:      p.BeforeID,
+		HideSensitive: hide,
+		PageSize:      p.Limit,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve bookmarks", err)
+		return
+	}
+
+	chirps := make([]database.Chirp, len(rows))
+	for i, row := range rows {
+		chirps[i] = row.Chirp
+	}
+	responseChirps, err := cfg.buildChirps(req.Context(), chirps, userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve bookmarks", err)
+		return
+	}
+
+	response := make([]bookmarkS, len(rows))
+	for i, row := range rows {
+		response[i] = bookmarkS{BookmarkedAt: row.BookmarkedAt, Chirp: responseChirps[i]}
+	}
+	responseWithJSON(w, http.StatusOK, response)
+}
@@ -1,137 +1,437 @@
 package main
 
 import (
-	"chirpy/internal/auth"
+	"chirpy/internal/chirptext"
 	"chirpy/internal/database"
+	"chirpy/internal/moderation"
+	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
-func validateChirp(body string) (string, error) {
-	const maxChirpLength = 140
-	if len(body) > maxChirpLength {
-		return "", errors.New("chirp is too long")
-	}
-	forbidden_words := []string{"kerfuffle", "sharbert", "fornax"}
-	cleaned := censor_words(body, forbidden_words)
-	return cleaned, nil
-}
-
-func censor_words(text string, forbidden_words []string) string {
-	replacement := "****"
-	aux := text
-	curr := 0
-	max := len(forbidden_words)
-
-	for {
-		if curr < max {
-			target := strings.Index(strings.ToLower(aux), forbidden_words[curr])
-			if target != -1 {
-				aux = aux[:target] + replacement + aux[target+len(forbidden_words[curr]):]
-			} else {
-				curr += 1
-			}
-		} else {
-			break
+const defaultTier = "standard"
+
+const (
+	// maxChirpBytes bounds a chirp body before its length is counted. It is
+	// far more than any tier allows, even for a chirp made of long links.
+	maxChirpBytes = 16 << 10
+	// maxChirpRequestBytes bounds the request body of handlers that take a
+	// chirp, leaving room for the other fields.
+	maxChirpRequestBytes = 64 << 10
+)
+
+var errChirpTooLarge = errors.New("chirp is too large")
+
+// chirpLengthError reports a chirp that is longer than its author may post.
+type chirpLengthError struct {
+	Length int
+	Max    int
+}
+
+func (e *chirpLengthError) Error() string {
+	return fmt.Sprintf("chirp is %d characters long, the maximum is %d", e.Length, e.Max)
+}
+
+// parseChirpLimits parses a comma-separated list of tier=limit pairs, such as
+// "standard=140,premium=280". The default tier must be present.
+func parseChirpLimits(s string) (map[string]int, error) {
+	limits := make(map[string]int)
+	for _, pair := range strings.Split(s, ",") {
+		tier, limit, found := strings.Cut(strings.TrimSpace(pair), "=")
+		if !found {
+			return nil, fmt.Errorf("invalid tier limit %q", pair)
 		}
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid limit for tier %q", tier)
+		}
+		limits[tier] = n
+	}
+	if _, ok := limits[defaultTier]; !ok {
+		return nil, fmt.Errorf("missing limit for the %q tier", defaultTier)
 	}
-	return aux
+	return limits, nil
 }
 
-func (cfg *apiConfig) create_chirp(w http.ResponseWriter, req *http.Request) {
-	headerToken, bearerErr := auth.GetBearerToken(req.Header)
-	if bearerErr != nil {
-		respondWithError(w, http.StatusUnauthorized, "Bad bearer", bearerErr)
-		return
+// chirpLimit returns the maximum chirp length for the user's tier. Users on a
+// tier without a configured limit get the default tier's.
+func (cfg *apiConfig) chirpLimit(ctx context.Context, userID uuid.UUID) (int, error) {
+	tier, err := cfg.db.GetUserTier(ctx, userID)
+	if err != nil {
+		return 0, err
 	}
+	if limit, ok := cfg.chirpLimits[tier]; ok {
+		return limit, nil
+	}
+	return cfg.chirpLimits[defaultTier], nil
+}
 
-	userID, tokenErr := auth.ValidateJWT(headerToken, cfg.secret)
-	if tokenErr != nil {
-		respondWithError(w, http.StatusUnauthorized, "Bad token", tokenErr)
-		return
+// validateChirp normalises body, checks it against the author's length limit
+// and censors it. Length is counted by chirpLength.
+func (cfg *apiConfig) validateChirp(ctx context.Context, userID uuid.UUID, body string) (string, error) {
+	if len(body) > maxChirpBytes {
+		return "", errChirpTooLarge
+	}
+	body, err := chirptext.Normalize(body)
+	if err != nil {
+		return "", err
 	}
 
-	type parameters struct {
-		Body string `json:"body"`
+	maxChirpLength, err := cfg.chirpLimit(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	if length := chirpLength(body); length > maxChirpLength {
+		return "", &chirpLengthError{Length: length, Max: maxChirpLength}
 	}
 
-	type successS struct {
-		ID        uuid.UUID `json:"id"`
-		CreatedAt time.Time `json:"created_at"`
-		UpdatedAt time.Time `json:"updated_at"`
-		Body      string    `json:"body"`
-		UserID    uuid.UUID `json:"user_id"`
+	return cfg.filter.Censor(body), nil
+}
+
+const maxContentWarningLength = 100
+
+// Bounds on the expires_in of an ephemeral chirp.
+const (
+	minChirpLifetime = time.Minute
+	maxChirpLifetime = 7 * 24 * time.Hour
+)
+
+// contentWarning validates and censors an optional content warning. A
+// missing or blank warning means none.
+func (cfg *apiConfig) contentWarning(warning *string) (sql.NullString, error) {
+	if warning == nil {
+		return sql.NullString{}, nil
+	}
+	text, err := chirptext.Normalize(*warning)
+	if errors.Is(err, chirptext.ErrEmpty) {
+		return sql.NullString{}, nil
+	}
+	if err != nil {
+		return sql.NullString{}, err
 	}
+	if chirptext.Length(text) > maxContentWarningLength {
+		return sql.NullString{}, fmt.Errorf("content warnings must be at most %d characters", maxContentWarningLength)
+	}
+	return sql.NullString{String: cfg.filter.Censor(text), Valid: true}, nil
+}
 
-	decoder := json.NewDecoder(req.Body)
-	params := parameters{}
-	err := decoder.Decode(&params)
+// hideSensitive reads the ?hide_sensitive= listing filter, which drops
+// chirps that are sensitive or carry a content warning.
+func hideSensitive(req *http.Request) (bool, error) {
+	value := req.URL.Query().Get("hide_sensitive")
+	if value == "" {
+		return false, nil
+	}
+	hide, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, errors.New("hide_sensitive must be true or false")
+	}
+	return hide, nil
+}
+
+// respondWithChirpError writes the response for an error from validateChirp
+// or screenChirp.
+func respondWithChirpError(w http.ResponseWriter, err error) {
+	type lengthErrorResponse struct {
+		Error     string `json:"error"`
+		Length    int    `json:"length"`
+		MaxLength int    `json:"max_length"`
+	}
 
+	var lengthErr *chirpLengthError
+	var rejection *automodRejection
+	switch {
+	case errors.As(err, &lengthErr):
+		responseWithJSON(w, http.StatusBadRequest, lengthErrorResponse{
+			Error:     "Chirp is too long",
+			Length:    lengthErr.Length,
+			MaxLength: lengthErr.Max,
+		})
+	case errors.Is(err, errChirpTooLarge):
+		respondWithError(w, http.StatusBadRequest, "Chirp is too long", nil)
+	case errors.Is(err, chirptext.ErrEmpty):
+		respondWithError(w, http.StatusBadRequest, "Chirp is empty", nil)
+	case errors.As(err, &rejection):
+		respondWithError(w, http.StatusBadRequest, "Chirp rejected", rejection)
+	default:
+		respondWithError(w, http.StatusInternalServerError, "Could not validate chirp", err)
+	}
+}
+
+// decodeChirpRequest decodes the JSON body of a request that carries a chirp
+// into params, refusing bodies over maxChirpRequestBytes. On failure it
+// writes the error response and returns false.
+func decodeChirpRequest(w http.ResponseWriter, req *http.Request, params any) bool {
+	req.Body = http.MaxBytesReader(w, req.Body, maxChirpRequestBytes)
+	err := json.NewDecoder(req.Body).Decode(params)
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		respondWithError(w, http.StatusRequestEntityTooLarge, "Request is too large", err)
+		return false
+	}
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return false
+	}
+	return true
+}
+
+// chirpParameters is the content of a new chirp, as posted to create_chirp
+// or saved in a draft.
+type chirpParameters struct {
+	Body           string                 `json:"body"`
+	QuoteOfID      *uuid.UUID             `json:"quote_of_id"`
+	ContentWarning *string                `json:"content_warning"`
+	Sensitive      bool                   `json:"sensitive"`
+	Visibility     string                 `json:"visibility"`
+	PublishAt      *time.Time             `json:"publish_at"`
+	ExpiresIn      string                 `json:"expires_in"`
+	Media          []attachmentParameters `json:"media"`
+	Poll           *pollParameters        `json:"poll"`
+}
+
+// preparedChirp is a new chirp that passed validation and automated
+// moderation, ready to be written with createChirp.
+type preparedChirp struct {
+	params      database.CreateChirpParams
+	results     []moderation.Result
+	poll        *preparedPoll
+	attachments []database.CreateChirpAttachmentParams
+}
+
+// prepareChirp validates, censors and screens a new chirp by userID. If the
+// chirp cannot be posted it writes the error response and returns false.
+func (cfg *apiConfig) prepareChirp(w http.ResponseWriter, req *http.Request, userID uuid.UUID, params chirpParameters) (preparedChirp, bool) {
+	cleanedBody, err := cfg.validateChirp(req.Context(), userID, params.Body)
+	if err != nil {
+		respondWithChirpError(w, err)
+		return preparedChirp{}, false
+	}
+
+	visibility := visibilityPublic
+	if params.Visibility != "" {
+		if !visibilities[params.Visibility] {
+			respondWithError(w, http.StatusBadRequest, "Unknown visibility", nil)
+			return preparedChirp{}, false
+		}
+		visibility = params.Visibility
+	}
+
+	warning, err := cfg.contentWarning(params.ContentWarning)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return preparedChirp{}, false
+	}
+
+	attachments, ok := cfg.prepareAttachments(w, req, userID, params.Media)
+	if !ok {
+		return preparedChirp{}, false
+	}
+
+	held, results, err := cfg.screenChirp(req.Context(), userID, uuid.NullUUID{}, cleanedBody)
+	if err != nil {
+		respondWithChirpError(w, err)
+		return preparedChirp{}, false
+	}
+
+	scheduledFor := sql.NullTime{}
+	publishedAt := time.Now().UTC()
+	if params.PublishAt != nil && params.PublishAt.After(publishedAt) {
+		scheduledFor = sql.NullTime{Time: params.PublishAt.UTC(), Valid: true}
+		publishedAt = scheduledFor.Time
+	}
+
+	expiresAt := sql.NullTime{}
+	if params.ExpiresIn != "" {
+		lifetime, err := time.ParseDuration(params.ExpiresIn)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Bad expires_in duration", err)
+			return preparedChirp{}, false
+		}
+		if lifetime < minChirpLifetime || lifetime > maxChirpLifetime {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("expires_in must be between %s and %s", minChirpLifetime, maxChirpLifetime), nil)
+			return preparedChirp{}, false
+		}
+		expiresAt = sql.NullTime{Time: publishedAt.Add(lifetime), Valid: true}
+	}
+
+	var poll *preparedPoll
+	if params.Poll != nil {
+		prepared, err := cfg.preparePoll(*params.Poll, publishedAt)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+			return preparedChirp{}, false
+		}
+		poll = &prepared
+	}
+
+	quoteOf := uuid.NullUUID{}
+	if params.QuoteOfID != nil {
+		original, err := cfg.originalChirp(req.Context(), *params.QuoteOfID, userID)
+		if err != nil {
+			respondWithError(w, http.StatusNotFound, "Quoted chirp not found", err)
+			return preparedChirp{}, false
+		}
+		if !shareable(original) {
+			respondWithError(w, http.StatusForbidden, "Only public and unlisted chirps can be quoted", nil)
+			return preparedChirp{}, false
+		}
+		quoteOf = uuid.NullUUID{UUID: original.ID, Valid: true}
+	}
+
+	return preparedChirp{
+		params: database.CreateChirpParams{
+			Body:           cleanedBody,
+			UserID:         userID,
+			QuoteOf:        quoteOf,
+			HeldAt:         sql.NullTime{Time: time.Now().UTC(), Valid: held},
+			ContentWarning: warning,
+			Sensitive:      params.Sensitive,
+			Visibility:     visibility,
+			ScheduledFor:   scheduledFor,
+			ExpiresAt:      expiresAt,
+		},
+		results:     results,
+		poll:        poll,
+		attachments: attachments,
+	}, true
+}
+
+// createChirp writes a prepared chirp. Held and scheduled chirps are indexed
+// once they become visible, so nobody is notified about a chirp they cannot
+// see.
+func createChirp(ctx context.Context, q *database.Queries, prepared preparedChirp) (database.Chirp, error) {
+	chirp, err := q.CreateChirp(ctx, prepared.params)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	if prepared.poll != nil {
+		if err := createPoll(ctx, q, chirp.ID, *prepared.poll); err != nil {
+			return database.Chirp{}, err
+		}
+	}
+	for _, attachment := range prepared.attachments {
+		attachment.ChirpID = chirp.ID
+		if err := q.CreateChirpAttachment(ctx, attachment); err != nil {
+			return database.Chirp{}, err
+		}
+	}
+
+	switch {
+	case chirp.HeldAt.Valid:
+		err = saveAutomodResults(ctx, q, chirp.UserID, uuid.NullUUID{UUID: chirp.ID, Valid: true}, chirp.Body, prepared.results)
+	case !chirp.ScheduledFor.Valid:
+		err = saveChirpEntities(ctx, q, chirp)
+	}
+	return chirp, err
+}
+
+// respondWithNewChirp writes a chirp that was just created: 201 if it is
+// live, or 202 if it is held for review or scheduled.
+func (cfg *apiConfig) respondWithNewChirp(w http.ResponseWriter, req *http.Request, chirp database.Chirp) {
+	response, err := cfg.buildChirp(req.Context(), chirp, chirp.UserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not build chirp", err)
+		return
+	}
+	if chirp.HeldAt.Valid || chirp.ScheduledFor.Valid {
+		responseWithJSON(w, http.StatusAccepted, response)
+		return
+	}
+	responseWithJSON(w, http.StatusCreated, response)
+}
+
+func (cfg *apiConfig) create_chirp(w http.ResponseWriter, req *http.Request) {
+	userID, ok := cfg.authenticate(w, req)
+	if !ok {
 		return
 	}
 
-	cleanedBody, err := validateChirp(params.Body)
+	params := chirpParameters{}
+	if !decodeChirpRequest(w, req, &params) {
+		return
+	}
+
+	prepared, ok := cfg.prepareChirp(w, req, userID, params)
+	if !ok {
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(req.Context(), nil)
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Chirp is too long", nil)
+		respondWithError(w, http.StatusInternalServerError, "Could not create chirp", err)
 		return
 	}
+	defer tx.Rollback()
 
-	chirp, err := cfg.db.CreateChirp(req.Context(), database.CreateChirpParams{Body: cleanedBody, UserID: userID})
+	chirp, err := createChirp(req.Context(), cfg.db.WithTx(tx), prepared)
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Could not create chirp", err)
+		respondWithError(w, http.StatusInternalServerError, "Could not create chirp", err)
 		return
 	}
 
-	responseWithJSON(w, http.StatusCreated, successS{
-		ID:        chirp.ID,
-		CreatedAt: chirp.CreatedAt,
-		UpdatedAt: chirp.UpdatedAt,
-		Body:      chirp.Body,
-		UserID:    chirp.UserID,
-	})
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not create chirp", err)
+		return
+	}
+
+	cfg.respondWithNewChirp(w, req, chirp)
 }
 
+// get_chirps lists public chirps, oldest first. With ?author_id= it lists
+// the chirps by that author that the caller may read instead, starting with
+// their pinned chirps, most recently pinned first.
 func (cfg *apiConfig) get_chirps(w http.ResponseWriter, req *http.Request) {
-	type successS struct {
-		ID        uuid.UUID `json:"id"`
-		CreatedAt time.Time `json:"created_at"`
-		UpdatedAt time.Time `json:"updated_at"`
-		Body      string    `json:"body"`
-		UserID    uuid.UUID `json:"user_id"`
+	viewerID, ok := cfg.viewer(w, req)
+	if !ok {
+		return
 	}
-	chirps, err := cfg.db.GetChirps(req.Context())
+
+	hide, err := hideSensitive(req)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Could not retrieve chirps", err)
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
 		return
 	}
 
-	responseChirps := make([]successS, len(chirps))
-	for i, chirp := range chirps {
-		responseChirps[i] = successS{
-			ID:        chirp.ID,
-			CreatedAt: chirp.CreatedAt,
-			UpdatedAt: chirp.UpdatedAt,
-			Body:      chirp.Body,
-			UserID:    chirp.UserID,
+	var chirps []database.Chirp
+	if author := req.URL.Query().Get("author_id"); author != "" {
+		authorID, err := uuid.Parse(author)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Bad author ID", err)
+			return
 		}
+		chirps, err = cfg.authorChirps(req.Context(), authorID, viewerID, hide)
+	} else {
+		chirps, err = cfg.db.GetChirps(req.Context(), hide)
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve chirps", err)
+		return
+	}
+
+	responseChirps, err := cfg.buildChirps(req.Context(), chirps, viewerID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve chirps", err)
+		return
 	}
 	responseWithJSON(w, http.StatusOK, responseChirps)
 }
 
+// get_chirp returns a single chirp if the caller may read it. Chirps the
+// caller may not read are reported as missing so their existence is not
+// revealed; followers-only chirps need a token.
 func (cfg *apiConfig) get_chirp(w http.ResponseWriter, req *http.Request) {
-	type successS struct {
-		ID        uuid.UUID `json:"id"`
-		CreatedAt time.Time `json:"created_at"`
-		UpdatedAt time.Time `json:"updated_at"`
-		Body      string    `json:"body"`
-		UserID    uuid.UUID `json:"user_id"`
+	userID, ok := cfg.viewer(w, req)
+	if !ok {
+		return
 	}
 
 	pathId := req.PathValue("chirpID")
@@ -141,17 +441,338 @@ func (cfg *apiConfig) get_chirp(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	chirp, err := cfg.db.GetChirp(req.Context(), id)
+	chirp, err := cfg.db.GetChirp(req.Context(), database.GetChirpParams{ID: id, ViewerID: userID})
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Not found", err)
+		return
+	}
+
+	response, err := cfg.buildChirp(req.Context(), chirp, userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not build chirp", err)
+		return
+	}
+	responseWithJSON(w, http.StatusOK, response)
+}
+
+func (cfg *apiConfig) delete_chirp(w http.ResponseWriter, req *http.Request) {
+	userID, ok := cfg.authenticate(w, req)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Bad ID", err)
+		return
+	}
+
+	chirp, err := cfg.db.GetChirp(req.Context(), database.GetChirpParams{ID: id, ViewerID: userID})
 	if err != nil {
 		respondWithError(w, http.StatusNotFound, "Not found", err)
 		return
 	}
+	if chirp.UserID != userID {
+		respondWithError(w, http.StatusForbidden, "You can only delete your own chirps", nil)
+		return
+	}
 
-	responseWithJSON(w, http.StatusOK, successS{
-		ID:        chirp.ID,
-		CreatedAt: chirp.CreatedAt,
-		UpdatedAt: chirp.UpdatedAt,
+	// Rechirps have no content worth recovering, and a soft-deleted one would
+	// stop the user from rechirping the same chirp again.
+	if chirp.RechirpOf.Valid {
+		_, err = cfg.db.DeleteRechirp(req.Context(), database.DeleteRechirpParams{UserID: userID, RechirpOf: chirp.RechirpOf})
+	} else {
+		_, err = cfg.db.SoftDeleteChirp(req.Context(), database.SoftDeleteChirpParams{ID: chirp.ID, UserID: userID})
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not delete chirp", err)
+		return
+	}
+
+	responseWithJSON(w, http.StatusNoContent, nil)
+}
+
+func (cfg *apiConfig) rechirp(w http.ResponseWriter, req *http.Request) {
+	userID, ok := cfg.authenticate(w, req)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Bad ID", err)
+		return
+	}
+
+	original, err := cfg.originalChirp(req.Context(), id, userID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Not found", err)
+		return
+	}
+	if !shareable(original) {
+		respondWithError(w, http.StatusForbidden, "Only public and unlisted chirps can be rechirped", nil)
+		return
+	}
+
+	chirp, err := cfg.db.CreateRechirp(req.Context(), database.CreateRechirpParams{
+		UserID:    userID,
+		RechirpOf: uuid.NullUUID{UUID: original.ID, Valid: true},
+		// A rechirp of an unlisted chirp stays out of listings too, and a
+		// rechirp of an ephemeral chirp expires with it.
+		Visibility: original.Visibility,
+		ExpiresAt:  original.ExpiresAt,
+	})
+	if isUniqueViolation(err) {
+		respondWithError(w, http.StatusConflict, "Already rechirped", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not rechirp", err)
+		return
+	}
+
+	response, err := cfg.buildChirp(req.Context(), chirp, userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not build chirp", err)
+		return
+	}
+	responseWithJSON(w, http.StatusCreated, response)
+}
+
+func (cfg *apiConfig) undo_rechirp(w http.ResponseWriter, req *http.Request) {
+	userID, ok := cfg.authenticate(w, req)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Bad ID", err)
+		return
+	}
+
+	original, err := cfg.originalChirp(req.Context(), id, userID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Not found", err)
+		return
+	}
+
+	deleted, err := cfg.db.DeleteRechirp(req.Context(), database.DeleteRechirpParams{
+		UserID:    userID,
+		RechirpOf: uuid.NullUUID{UUID: original.ID, Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not undo rechirp", err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Not rechirped", nil)
+		return
+	}
+
+	responseWithJSON(w, http.StatusNoContent, nil)
+}
+
+func (cfg *apiConfig) edit_chirp(w http.ResponseWriter, req *http.Request) {
+	userID, ok := cfg.authenticate(w, req)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Bad ID", err)
+		return
+	}
+
+	type parameters struct {
+		Body string `json:"body"`
+	}
+
+	params := parameters{}
+	if !decodeChirpRequest(w, req, &params) {
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(req.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not edit chirp", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	// The chirp stays locked until the edit is written, so concurrent edits
+	// each save the body they replace as a revision.
+	chirp, err := qtx.GetChirpForUpdate(req.Context(), database.GetChirpForUpdateParams{ID: id, ViewerID: userID})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Not found", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve chirp", err)
+		return
+	}
+	if chirp.UserID != userID {
+		respondWithError(w, http.StatusForbidden, "You can only edit your own chirps", nil)
+		return
+	}
+	if chirp.RechirpOf.Valid {
+		respondWithError(w, http.StatusBadRequest, "Rechirps cannot be edited", nil)
+		return
+	}
+	if time.Now().UTC().Sub(chirp.CreatedAt) > cfg.editWindow {
+		respondWithError(w, http.StatusForbidden, "Edit window has passed", nil)
+		return
+	}
+
+	cleanedBody, err := cfg.validateChirp(req.Context(), userID, params.Body)
+	if err != nil {
+		respondWithChirpError(w, err)
+		return
+	}
+	held, results, err := cfg.screenChirp(req.Context(), userID, uuid.NullUUID{UUID: chirp.ID, Valid: true}, cleanedBody)
+	if err != nil {
+		respondWithChirpError(w, err)
+		return
+	}
+
+	err = qtx.CreateChirpRevision(req.Context(), database.CreateChirpRevisionParams{
+		ChirpID:   chirp.ID,
 		Body:      chirp.Body,
-		UserID:    chirp.UserID,
+		WrittenAt: chirp.UpdatedAt,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not save revision", err)
+		return
+	}
+
+	edited, err := qtx.UpdateChirpBody(req.Context(), database.UpdateChirpBodyParams{
+		Body:   cleanedBody,
+		HeldAt: sql.NullTime{Time: time.Now().UTC(), Valid: held},
+		ID:     chirp.ID,
 	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not edit chirp", err)
+		return
+	}
+
+	// A held edit takes the chirp down until it is reviewed, and approving
+	// it re-indexes the chirp.
+	if edited.HeldAt.Valid {
+		err = saveAutomodResults(req.Context(), qtx, userID, uuid.NullUUID{UUID: edited.ID, Valid: true}, edited.Body, results)
+	} else {
+		err = replaceChirpEntities(req.Context(), qtx, edited)
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not save chirp entities", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not edit chirp", err)
+		return
+	}
+
+	response, err := cfg.buildChirp(req.Context(), edited, userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not build chirp", err)
+		return
+	}
+	if edited.HeldAt.Valid {
+		responseWithJSON(w, http.StatusAccepted, response)
+		return
+	}
+	responseWithJSON(w, http.StatusOK, response)
+}
+
+func (cfg *apiConfig) get_chirp_history(w http.ResponseWriter, req *http.Request) {
+	userID, ok := cfg.viewer(w, req)
+	if !ok {
+		return
+	}
+
+	type revisionS struct {
+		Body       string    `json:"body"`
+		WrittenAt  time.Time `json:"written_at"`
+		ReplacedAt time.Time `json:"replaced_at"`
+	}
+
+	id, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Bad ID", err)
+		return
+	}
+
+	chirp, err := cfg.db.GetChirp(req.Context(), database.GetChirpParams{ID: id, ViewerID: userID})
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Not found", err)
+		return
+	}
+
+	revisions, err := cfg.db.GetChirpRevisions(req.Context(), chirp.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve history", err)
+		return
+	}
+
+	response := make([]revisionS, len(revisions))
+	for i, revision := range revisions {
+		response[i] = revisionS{
+			Body:       revision.Body,
+			WrittenAt:  revision.WrittenAt,
+			ReplacedAt: revision.CreatedAt,
+		}
+	}
+	responseWithJSON(w, http.StatusOK, response)
+}
+
+func (cfg *apiConfig) get_mentions(w http.ResponseWriter, req *http.Request) {
+	userID, ok := cfg.authenticate(w, req)
+	if !ok {
+		return
+	}
+
+	hide, err := hideSensitive(req)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	chirps, err := cfg.db.GetChirpsMentioningUser(req.Context(), database.GetChirpsMentioningUserParams{
+		UserID:        userID,
+		HideSensitive: hide,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve mentions", err)
+		return
+	}
+
+	responseChirps, err := cfg.buildChirps(req.Context(), chirps, userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve mentions", err)
+		return
+	}
+	responseWithJSON(w, http.StatusOK, responseChirps)
+}
+
+// shareable reports whether chirp may be rechirped or quoted. Sharing a
+// followers-only or private chirp would show it to people it was not meant
+// for.
+func shareable(chirp database.Chirp) bool {
+	return chirp.Visibility == visibilityPublic || chirp.Visibility == visibilityUnlisted
+}
+
+// originalChirp loads the chirp with the given ID as seen by viewerID,
+// following a rechirp to the chirp it reposts so that rechirps and quotes
+// always point at original content.
+func (cfg *apiConfig) originalChirp(ctx context.Context, id, viewerID uuid.UUID) (database.Chirp, error) {
+	chirp, err := cfg.db.GetChirp(ctx, database.GetChirpParams{ID: id, ViewerID: viewerID})
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	if chirp.RechirpOf.Valid {
+		return cfg.db.GetChirp(ctx, database.GetChirpParams{ID: chirp.RechirpOf.UUID, ViewerID: viewerID})
+	}
+	return chirp, nil
 }
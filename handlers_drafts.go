@@ -0,0 +1,308 @@
+package main
+
+import (
+	"chirpy/internal/chirptext"
+	"chirpy/internal/database"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// maxDraftLength bounds what can be saved as a draft. Drafts are only
+// checked against the author's chirp length limit when published, so this is
+// deliberately generous.
+const maxDraftLength = 5000
+
+// Draft is the JSON representation of an unpublished chirp.
+type Draft struct {
+	ID             uuid.UUID  `json:"id"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	Body           string     `json:"body"`
+	QuoteOfID      *uuid.UUID `json:"quote_of_id"`
+	ContentWarning *string    `json:"content_warning"`
+	Sensitive      bool       `json:"sensitive"`
+	Visibility     string     `json:"visibility"`
+}
+
+func draftFromDB(draft database.Draft) Draft {
+	response := Draft{
+		ID:         draft.ID,
+		CreatedAt:  draft.CreatedAt,
+		UpdatedAt:  draft.UpdatedAt,
+		Body:       draft.Body,
+		Sensitive:  draft.Sensitive,
+		Visibility: draft.Visibility,
+	}
+	if draft.QuoteOf.Valid {
+		response.QuoteOfID = &draft.QuoteOf.UUID
+	}
+	if draft.ContentWarning.Valid {
+		response.ContentWarning = &draft.ContentWarning.String
+	}
+	return response
+}
+
+// draftContent is the editable part of a draft, stored as written.
+type draftContent struct {
+	Body           string
+	QuoteOf        uuid.NullUUID
+	ContentWarning sql.NullString
+	Sensitive      bool
+	Visibility     string
+}
+
+// decodeDraft reads a draft from the request body. Drafts are work in
+// progress, so only what is needed to store them is checked here; everything
+// else is checked when the draft is published. On failure it writes the
+// error response and returns false.
+func (cfg *apiConfig) decodeDraft(w http.ResponseWriter, req *http.Request, userID uuid.UUID) (draftContent, bool) {
+	type parameters struct {
+		Body           string     `json:"body"`
+		QuoteOfID      *uuid.UUID `json:"quote_of_id"`
+		ContentWarning *string    `json:"content_warning"`
+		Sensitive      bool       `json:"sensitive"`
+		Visibility     string     `json:"visibility"`
+	}
+
+	params := parameters{}
+	if !decodeChirpRequest(w, req, &params) {
+		return draftContent{}, false
+	}
+
+	if chirptext.Length(params.Body) > maxDraftLength {
+		respondWithError(w, http.StatusBadRequest, "Draft is too long", nil)
+		return draftContent{}, false
+	}
+
+	content := draftContent{
+		Body:       params.Body,
+		Sensitive:  params.Sensitive,
+		Visibility: visibilityPublic,
+	}
+	if params.Visibility != "" {
+		if !visibilities[params.Visibility] {
+			respondWithError(w, http.StatusBadRequest, "Unknown visibility", nil)
+			return draftContent{}, false
+		}
+		content.Visibility = params.Visibility
+	}
+	if params.ContentWarning != nil {
+		content.ContentWarning = sql.NullString{String: *params.ContentWarning, Valid: true}
+	}
+	if params.QuoteOfID != nil {
+		original, err := cfg.originalChirp(req.Context(), *params.QuoteOfID, userID)
+		if err != nil {
+			respondWithError(w, http.StatusNotFound, "Quoted chirp not found", err)
+			return draftContent{}, false
+		}
+		content.QuoteOf = uuid.NullUUID{UUID: original.ID, Valid: true}
+	}
+	return content, true
+}
+
+func (cfg *apiConfig) create_draft(w http.ResponseWriter, req *http.Request) {
+	userID, ok := cfg.authenticate(w, req)
+	if !ok {
+		return
+	}
+
+	content, ok := cfg.decodeDraft(w, req, userID)
+	if !ok {
+		return
+	}
+
+	draft, err := cfg.db.CreateDraft(req.Context(), database.CreateDraftParams{
+		UserID:         userID,
+		Body:           content.Body,
+		QuoteOf:        content.QuoteOf,
+		ContentWarning: content.ContentWarning,
+		Sensitive:      content.Sensitive,
+		Visibility:     content.Visibility,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not save draft", err)
+		return
+	}
+
+	responseWithJSON(w, http.StatusCreated, draftFromDB(draft))
+}
+
+// get_drafts lists the caller's drafts, most recently edited first.
+func (cfg *apiConfig) get_drafts(w http.ResponseWriter, req *http.Request) {
+	userID, ok := cfg.authenticate(w, req)
+	if !ok {
+		return
+	}
+
+	drafts, err := cfg.db.GetDrafts(req.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve drafts", err)
+		return
+	}
+
+	response := make([]Draft, len(drafts))
+	for i, draft := range drafts {
+		response[i] = draftFromDB(draft)
+	}
+	responseWithJSON(w, http.StatusOK, response)
+}
+
+func (cfg *apiConfig) get_draft(w http.ResponseWriter, req *http.Request) {
+	userID, ok := cfg.authenticate(w, req)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(req.PathValue("draftID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Bad ID", err)
+		return
+	}
+
+	draft, err := cfg.db.GetDraft(req.Context(), database.GetDraftParams{ID: id, UserID: userID})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Draft not found", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve draft", err)
+		return
+	}
+
+	responseWithJSON(w, http.StatusOK, draftFromDB(draft))
+}
+
+// update_draft replaces the content of a draft.
+func (cfg *apiConfig) update_draft(w http.ResponseWriter, req *http.Request) {
+	userID, ok := cfg.authenticate(w, req)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(req.PathValue("draftID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Bad ID", err)
+		return
+	}
+
+	content, ok := cfg.decodeDraft(w, req, userID)
+	if !ok {
+		return
+	}
+
+	draft, err := cfg.db.UpdateDraft(req.Context(), database.UpdateDraftParams{
+		Body:           content.Body,
+		QuoteOf:        content.QuoteOf,
+		ContentWarning: content.ContentWarning,
+		Sensitive:      content.Sensitive,
+		Visibility:     content.Visibility,
+		ID:             id,
+		UserID:         userID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Draft not found", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not save draft", err)
+		return
+	}
+
+	responseWithJSON(w, http.StatusOK, draftFromDB(draft))
+}
+
+func (cfg *apiConfig) delete_draft(w http.ResponseWriter, req *http.Request) {
+	userID, ok := cfg.authenticate(w, req)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(req.PathValue("draftID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Bad ID", err)
+		return
+	}
+
+	deleted, err := cfg.db.DeleteDraft(req.Context(), database.DeleteDraftParams{ID: id, UserID: userID})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not delete draft", err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Draft not found", nil)
+		return
+	}
+
+	responseWithJSON(w, http.StatusNoContent, nil)
+}
+
+// publish_draft turns a draft into a chirp. The draft goes through the same
+// validation and moderation as create_chirp, and is deleted in the same
+// transaction that writes the chirp, so it is published at most once.
+func (cfg *apiConfig) publish_draft(w http.ResponseWriter, req *http.Request) {
+	userID, ok := cfg.authenticate(w, req)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(req.PathValue("draftID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Bad ID", err)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(req.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not publish draft", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	// Deleting first claims the draft and reads it in one step: a concurrent
+	// publish or update blocks on the row, so what is published is exactly
+	// what was deleted. If the chirp is rejected, the rollback keeps it.
+	draft, err := qtx.ClaimDraft(req.Context(), database.ClaimDraftParams{ID: id, UserID: userID})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Draft not found", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not publish draft", err)
+		return
+	}
+
+	params := chirpParameters{
+		Body:       draft.Body,
+		Sensitive:  draft.Sensitive,
+		Visibility: draft.Visibility,
+	}
+	if draft.QuoteOf.Valid {
+		params.QuoteOfID = &draft.QuoteOf.UUID
+	}
+	if draft.ContentWarning.Valid {
+		params.ContentWarning = &draft.ContentWarning.String
+	}
+
+	prepared, ok := cfg.prepareChirp(w, req, userID, params)
+	if !ok {
+		return
+	}
+
+	chirp, err := createChirp(req.Context(), qtx, prepared)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not publish draft", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not publish draft", err)
+		return
+	}
+
+	cfg.respondWithNewChirp(w, req, chirp)
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"chirpy/internal/database"
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+func (cfg *apiConfig) follow_user(w http.ResponseWriter, req *http.Request) {
+	userID, ok := cfg.authenticate(w, req)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(req.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Bad ID", err)
+		return
+	}
+	if id == userID {
+		respondWithError(w, http.StatusBadRequest, "Cannot follow yourself", nil)
+		return
+	}
+
+	_, err = cfg.db.GetUser(req.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Not found", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not follow user", err)
+		return
+	}
+
+	// The follow and its notification commit together, so a retry after a
+	// failure notifies the user rather than finding the follow already there.
+	tx, err := cfg.dbConn.BeginTx(req.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not follow user", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	followed, err := qtx.FollowUser(req.Context(), database.FollowUserParams{FollowerID: userID, FolloweeID: id})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not follow user", err)
+		return
+	}
+	if followed == 0 {
+		responseWithJSON(w, http.StatusNoContent, nil)
+		return
+	}
+
+	err = notify(req.Context(), qtx, Notification{
+		UserID:  id,
+		Kind:    NotificationNewFollower,
+		ActorID: uuid.NullUUID{UUID: userID, Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not notify user", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not follow user", err)
+		return
+	}
+
+	responseWithJSON(w, http.StatusNoContent, nil)
+}
+
+func (cfg *apiConfig) unfollow_user(w http.ResponseWriter, req *http.Request) {
+	userID, ok := cfg.authenticate(w, req)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(req.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Bad ID", err)
+		return
+	}
+
+	unfollowed, err := cfg.db.UnfollowUser(req.Context(), database.UnfollowUserParams{FollowerID: userID, FolloweeID: id})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not unfollow user", err)
+		return
+	}
+	if unfollowed == 0 {
+		respondWithError(w, http.StatusNotFound, "Not following", nil)
+		return
+	}
+
+	responseWithJSON(w, http.StatusNoContent, nil)
+}
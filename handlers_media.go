@@ -0,0 +1,282 @@
+package main
+
+import (
+	"bytes"
+	"chirpy/internal/chirptext"
+	"chirpy/internal/database"
+	"chirpy/internal/media"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+const (
+	maxAttachments    = 4
+	maxAltTextLength  = 1000
+	maxMediaBytes     = 5 << 20
+	maxMediaDimension = 4096
+)
+
+var mediaLimits = media.Limits{
+	MaxBytes:  maxMediaBytes,
+	MaxWidth:  maxMediaDimension,
+	MaxHeight: maxMediaDimension,
+}
+
+// Media is the JSON representation of an uploaded image. AltText is only set
+// when the image is attached to a chirp.
+type Media struct {
+	ID          uuid.UUID `json:"id"`
+	URL         string    `json:"url"`
+	ContentType string    `json:"content_type"`
+	Width       int       `json:"width"`
+	Height      int       `json:"height"`
+	AltText     string    `json:"alt_text,omitempty"`
+}
+
+func mediaURL(id uuid.UUID) string {
+	return "/api/media/" + id.String()
+}
+
+// attachmentParameters is an image attached to a new chirp.
+type attachmentParameters struct {
+	ID      uuid.UUID `json:"id"`
+	AltText string    `json:"alt_text"`
+}
+
+// prepareAttachments checks that userID uploaded every attached image and
+// cleans up their alt text. On failure it writes the error response and
+// returns false.
+func (cfg *apiConfig) prepareAttachments(w http.ResponseWriter, req *http.Request, userID uuid.UUID, params []attachmentParameters) ([]database.CreateChirpAttachmentParams, bool) {
+	if len(params) > maxAttachments {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Chirps can have at most %d images", maxAttachments), nil)
+		return nil, false
+	}
+
+	ids := make([]uuid.UUID, len(params))
+	seen := make(map[uuid.UUID]bool, len(params))
+	attachments := make([]database.CreateChirpAttachmentParams, len(params))
+	for i, param := range params {
+		if seen[param.ID] {
+			respondWithError(w, http.StatusBadRequest, "The same image is attached twice", nil)
+			return nil, false
+		}
+		seen[param.ID] = true
+		ids[i] = param.ID
+
+		altText, err := chirptext.Normalize(param.AltText)
+		if err != nil && !errors.Is(err, chirptext.ErrEmpty) {
+			respondWithError(w, http.StatusBadRequest, "Bad alt text", err)
+			return nil, false
+		}
+		if chirptext.Length(altText) > maxAltTextLength {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Alt text must be at most %d characters", maxAltTextLength), nil)
+			return nil, false
+		}
+		attachments[i] = database.CreateChirpAttachmentParams{
+			MediaID:  param.ID,
+			Position: int32(i),
+			AltText:  cfg.filter.Censor(altText),
+		}
+	}
+
+	if len(ids) == 0 {
+		return nil, true
+	}
+	owned, err := cfg.db.GetUserMediaFiles(req.Context(), database.GetUserMediaFilesParams{Ids: ids, UserID: userID})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve media", err)
+		return nil, false
+	}
+	if len(owned) != len(ids) {
+		respondWithError(w, http.StatusBadRequest, "Unknown media ID", nil)
+		return nil, false
+	}
+	return attachments, true
+}
+
+// chirpAttachments loads the images attached to the given chirps, keyed by
+// chirp ID, in order.
+func (cfg *apiConfig) chirpAttachments(ctx context.Context, chirpIDs []uuid.UUID) (map[uuid.UUID][]Media, error) {
+	rows, err := cfg.db.GetChirpAttachments(ctx, chirpIDs)
+	if err != nil {
+		return nil, err
+	}
+	attachments := make(map[uuid.UUID][]Media)
+	for _, row := range rows {
+		attachments[row.ChirpID] = append(attachments[row.ChirpID], Media{
+			ID:          row.ID,
+			URL:         mediaURL(row.ID),
+			ContentType: row.ContentType,
+			Width:       int(row.Width),
+			Height:      int(row.Height),
+			AltText:     row.AltText,
+		})
+	}
+	return attachments, nil
+}
+
+// uploadedFile returns the "file" field of a multipart form. On failure it
+// writes the error response and returns false.
+func uploadedFile(w http.ResponseWriter, req *http.Request) (multipart.File, bool) {
+	// Leave room for the rest of the form; media.Decode enforces the limit
+	// on the file itself.
+	req.Body = http.MaxBytesReader(w, req.Body, maxMediaBytes+1<<20)
+	file, _, err := req.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respondWithError(w, http.StatusRequestEntityTooLarge, "File is too large", err)
+			return nil, false
+		}
+		respondWithError(w, http.StatusBadRequest, "Expected an image in the file field", err)
+		return nil, false
+	}
+	return file, true
+}
+
+// upload_media accepts an image as the "file" field of a multipart form. The
+// image is validated and re-encoded before it is stored; the returned ID can
+// then be attached to a chirp.
+func (cfg *apiConfig) upload_media(w http.ResponseWriter, req *http.Request) {
+	userID, ok := cfg.authenticate(w, req)
+	if !ok {
+		return
+	}
+
+	file, ok := uploadedFile(w, req)
+	if !ok {
+		return
+	}
+	defer file.Close()
+
+	img, err := media.Process(file, mediaLimits)
+	if err != nil {
+		respondWithMediaError(w, err)
+		return
+	}
+
+	key := media.Key(img)
+	if err := cfg.storage.Put(req.Context(), key, bytes.NewReader(img.Data)); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not store file", err)
+		return
+	}
+
+	mediaFile, err := cfg.db.CreateMediaFile(req.Context(), database.CreateMediaFileParams{
+		UserID:      userID,
+		StorageKey:  key,
+		ContentType: img.ContentType,
+		Width:       int32(img.Width),
+		Height:      int32(img.Height),
+		Size:        int32(len(img.Data)),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not save media", err)
+		return
+	}
+
+	responseWithJSON(w, http.StatusCreated, Media{
+		ID:          mediaFile.ID,
+		URL:         mediaURL(mediaFile.ID),
+		ContentType: mediaFile.ContentType,
+		Width:       int(mediaFile.Width),
+		Height:      int(mediaFile.Height),
+	})
+}
+
+// respondWithMediaError writes the response for an error from media.Process.
+func respondWithMediaError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, media.ErrTooLarge):
+		respondWithError(w, http.StatusRequestEntityTooLarge, "File is too large", nil)
+	case errors.Is(err, media.ErrUnsupportedType):
+		respondWithError(w, http.StatusUnsupportedMediaType, "Only PNG, JPEG and GIF images are supported", nil)
+	case errors.Is(err, media.ErrInvalid), errors.Is(err, media.ErrDimensions):
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+	default:
+		respondWithError(w, http.StatusInternalServerError, "Could not process file", err)
+	}
+}
+
+// Cache policies for stored files. Stored files are content-addressed and
+// never change, but an image stops being served when its chirp is deleted or
+// taken down, so images are only cached briefly, and only privately unless a
+// public or unlisted chirp shows them.
+const (
+	cacheImmutable    = "public, max-age=31536000, immutable"
+	cachePublicMedia  = "public, max-age=300"
+	cachePrivateMedia = "private, max-age=300"
+)
+
+// get_media serves an uploaded image to anyone who may read a chirp it is
+// attached to. Uploaders can always see their own images, attached or not,
+// and moderators can see every image. Images the caller may not see are
+// reported as missing.
+func (cfg *apiConfig) get_media(w http.ResponseWriter, req *http.Request) {
+	viewerID, ok := cfg.viewer(w, req)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(req.PathValue("mediaID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Bad ID", err)
+		return
+	}
+
+	access, err := cfg.db.GetMediaFileAccess(req.Context(), database.GetMediaFileAccessParams{ViewerID: viewerID, ID: id})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Not found", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve media", err)
+		return
+	}
+
+	allowed := access.Readable || (viewerID != uuid.Nil && access.MediaFile.UserID == viewerID)
+	if !allowed && viewerID != uuid.Nil {
+		role, err := cfg.db.GetUserRole(req.Context(), viewerID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Could not retrieve media", err)
+			return
+		}
+		allowed = roleRank[role] >= roleRank[roleModerator]
+	}
+	if !allowed {
+		respondWithError(w, http.StatusNotFound, "Not found", nil)
+		return
+	}
+
+	cacheControl := cachePrivateMedia
+	if access.Public {
+		cacheControl = cachePublicMedia
+	}
+	cfg.serveStored(w, req, access.MediaFile.StorageKey, access.MediaFile.ContentType, cacheControl)
+}
+
+// serveStored writes the stored file under key with the given Cache-Control.
+func (cfg *apiConfig) serveStored(w http.ResponseWriter, req *http.Request, key, contentType, cacheControl string) {
+	file, err := cfg.storage.Open(req.Context(), key)
+	if errors.Is(err, media.ErrNotFound) {
+		respondWithError(w, http.StatusNotFound, "Not found", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not read file", err)
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", cacheControl)
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, file)
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"chirpy/internal/database"
+	"chirpy/internal/moderation"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// seedBannedWords adds every word in the file at path to the banned word
+// list. Words already on the list are left alone.
+func (cfg *apiConfig) seedBannedWords(ctx context.Context, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	words, err := moderation.LoadWords(file)
+	if err != nil {
+		return err
+	}
+
+	for _, word := range words {
+		err := cfg.db.AddBannedWord(ctx, database.AddBannedWordParams{Word: moderation.Normalize(word)})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bannedWordsReloadInterval bounds how long a replica keeps censoring with
+// an old word list after another replica changed it.
+const bannedWordsReloadInterval = 30 * time.Second
+
+// reloadBannedWords replaces the filter's word list with the one stored in
+// the database.
+func (cfg *apiConfig) reloadBannedWords(ctx context.Context) error {
+	words, err := cfg.db.GetBannedWords(ctx)
+	if err != nil {
+		return err
+	}
+	cfg.filter.SetWords(words)
+	return nil
+}
+
+// runBannedWordsReload reloads the banned word list every
+// bannedWordsReloadInterval until ctx is cancelled. The replica that changes
+// the list reloads it straight away; this brings the others up to date.
+func (cfg *apiConfig) runBannedWordsReload(ctx context.Context) {
+	ticker := time.NewTicker(bannedWordsReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := cfg.reloadBannedWords(ctx); err != nil {
+			log.Printf("Could not reload banned words: %s", err)
+		}
+	}
+}
+
+func (cfg *apiConfig) get_banned_words(w http.ResponseWriter, req *http.Request) {
+	if _, ok := cfg.requireRole(w, req, roleAdmin); !ok {
+		return
+	}
+
+	type successS struct {
+		Words []string `json:"words"`
+	}
+
+	responseWithJSON(w, http.StatusOK, successS{Words: cfg.filter.Words()})
+}
+
+func (cfg *apiConfig) add_banned_word(w http.ResponseWriter, req *http.Request) {
+	userID, ok := cfg.requireRole(w, req, roleAdmin)
+	if !ok {
+		return
+	}
+
+	type parameters struct {
+		Word string `json:"word"`
+	}
+
+	decoder := json.NewDecoder(req.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	word := moderation.Normalize(params.Word)
+	if word == "" {
+		respondWithError(w, http.StatusBadRequest, "Word is required", nil)
+		return
+	}
+
+	err = cfg.db.AddBannedWord(req.Context(), database.AddBannedWordParams{
+		Word:      word,
+		CreatedBy: uuid.NullUUID{UUID: userID, Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not add word", err)
+		return
+	}
+
+	if err := cfg.reloadBannedWords(req.Context()); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not reload word list", err)
+		return
+	}
+
+	responseWithJSON(w, http.StatusNoContent, nil)
+}
+
+func (cfg *apiConfig) delete_banned_word(w http.ResponseWriter, req *http.Request) {
+	if _, ok := cfg.requireRole(w, req, roleAdmin); !ok {
+		return
+	}
+
+	deleted, err := cfg.db.DeleteBannedWord(req.Context(), moderation.Normalize(req.PathValue("word")))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not delete word", err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "Not found", nil)
+		return
+	}
+
+	if err := cfg.reloadBannedWords(req.Context()); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not reload word list", err)
+		return
+	}
+
+	responseWithJSON(w, http.StatusNoContent, nil)
+}
+
+// set_chirp_sensitivity lets moderators add or remove a chirp's content
+// warning and sensitive flag. The author is told when either is set.
+func (cfg *apiConfig) set_chirp_sensitivity(w http.ResponseWriter, req *http.Request) {
+	moderatorID, ok := cfg.requireRole(w, req, roleModerator)
+	if !ok {
+		return
+	}
+
+	type parameters struct {
+		ContentWarning *string `json:"content_warning"`
+		Sensitive      bool    `json:"sensitive"`
+	}
+
+	id, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Bad ID", err)
+		return
+	}
+
+	decoder := json.NewDecoder(req.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	warning, err := cfg.contentWarning(params.ContentWarning)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(req.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not update chirp", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	chirp, err := qtx.UpdateChirpSensitivity(req.Context(), database.UpdateChirpSensitivityParams{
+		ContentWarning: warning,
+		Sensitive:      params.Sensitive,
+		ID:             id,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Not found", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not update chirp", err)
+		return
+	}
+
+	_, err = qtx.CreateModerationAction(req.Context(), database.CreateModerationActionParams{
+		ModeratorID: uuid.NullUUID{UUID: moderatorID, Valid: true},
+		Action:      "set_sensitivity",
+		ChirpID:     uuid.NullUUID{UUID: chirp.ID, Valid: true},
+		UserID:      uuid.NullUUID{UUID: chirp.UserID, Valid: true},
+		Note:        warning.String,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not record action", err)
+		return
+	}
+
+	if chirp.Sensitive || chirp.ContentWarning.Valid {
+		err = notify(req.Context(), qtx, Notification{
+			UserID:  chirp.UserID,
+			Kind:    NotificationChirpModerated,
+			ChirpID: uuid.NullUUID{UUID: chirp.ID, Valid: true},
+			Data: map[string]any{
+				"action":          "marked_sensitive",
+				"content_warning": chirp.ContentWarning.String,
+				"sensitive":       chirp.Sensitive,
+			},
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Could not notify author", err)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not update chirp", err)
+		return
+	}
+
+	response, err := cfg.buildChirp(req.Context(), chirp, moderatorID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not build chirp", err)
+		return
+	}
+	responseWithJSON(w, http.StatusOK, response)
+}
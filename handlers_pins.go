@@ -0,0 +1,129 @@
+package main
+
+import (
+	"chirpy/internal/database"
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// authorChirps lists the chirps by authorID that viewerID may read. Pinned
+// chirps come first and are left out of the rest of the list, so none
+// appears twice.
+func (cfg *apiConfig) authorChirps(ctx context.Context, authorID, viewerID uuid.UUID, hideSensitive bool) ([]database.Chirp, error) {
+	pinned, err := cfg.db.GetPinnedChirps(ctx, database.GetPinnedChirpsParams{
+		AuthorID:      authorID,
+		ViewerID:      viewerID,
+		HideSensitive: hideSensitive,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rest, err := cfg.db.GetChirpsByAuthor(ctx, database.GetChirpsByAuthorParams{
+		AuthorID:      authorID,
+		ViewerID:      viewerID,
+		HideSensitive: hideSensitive,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return append(pinned, rest...), nil
+}
+
+// pin_chirp pins one of the caller's own chirps to their profile, up to
+// cfg.pinLimit at a time. Pinning an already pinned chirp is a no-op.
+func (cfg *apiConfig) pin_chirp(w http.ResponseWriter, req *http.Request) {
+	userID, ok := cfg.authenticate(w, req)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Bad ID", err)
+		return
+	}
+
+	chirp, err := cfg.db.GetChirp(req.Context(), database.GetChirpParams{ID: id, ViewerID: userID})
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Not found", err)
+		return
+	}
+	if chirp.UserID != userID {
+		respondWithError(w, http.StatusForbidden, "You can only pin your own chirps", nil)
+		return
+	}
+	if chirp.RechirpOf.Valid {
+		respondWithError(w, http.StatusBadRequest, "Rechirps cannot be pinned", nil)
+		return
+	}
+	if chirp.PinnedAt.Valid {
+		responseWithJSON(w, http.StatusNoContent, nil)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(req.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not pin chirp", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	// Serialise pins by the same user so concurrent requests cannot both
+	// pass the limit check.
+	if err := qtx.LockUser(req.Context(), userID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not pin chirp", err)
+		return
+	}
+
+	pinned, err := qtx.CountPinnedChirps(req.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not pin chirp", err)
+		return
+	}
+	if pinned >= int64(cfg.pinLimit) {
+		respondWithError(w, http.StatusConflict, "Too many pinned chirps", nil)
+		return
+	}
+
+	if err := qtx.PinChirp(req.Context(), chirp.ID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not pin chirp", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not pin chirp", err)
+		return
+	}
+
+	responseWithJSON(w, http.StatusNoContent, nil)
+}
+
+func (cfg *apiConfig) unpin_chirp(w http.ResponseWriter, req *http.Request) {
+	userID, ok := cfg.authenticate(w, req)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Bad ID", err)
+		return
+	}
+
+	unpinned, err := cfg.db.UnpinChirp(req.Context(), database.UnpinChirpParams{ID: id, UserID: userID})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not unpin chirp", err)
+		return
+	}
+	if unpinned == 0 {
+		respondWithError(w, http.StatusNotFound, "Not pinned", nil)
+		return
+	}
+
+	responseWithJSON(w, http.StatusNoContent, nil)
+}
@@ -0,0 +1,559 @@
+package main
+
+import (
+	"chirpy/internal/database"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+const (
+	reportStatusOpen     = "open"
+	reportStatusResolved = "resolved"
+
+	maxReportDetailsLength = 1000
+)
+
+// reportReasons are the categories a report can be filed under. They must
+// match the CHECK constraint on reports.reason.
+var reportReasons = map[string]bool{
+	"spam":           true,
+	"harassment":     true,
+	"hate":           true,
+	"violence":       true,
+	"sexual":         true,
+	"self_harm":      true,
+	"misinformation": true,
+	"other":          true,
+}
+
+// Resolutions a moderator can apply to a report. Each is also recorded as a
+// moderation action.
+const (
+	resolutionDismiss       = "dismiss"
+	resolutionHideChirp     = "hide_chirp"
+	resolutionSuspendAuthor = "suspend_author"
+)
+
+const defaultSuspension = 7 * 24 * time.Hour
+
+// Report is the JSON representation of a report returned to moderators. A
+// report about a chirp that has since been purged keeps AboutChirp but loses
+// its ChirpID.
+type Report struct {
+	ID             uuid.UUID  `json:"id"`
+	ReporterID     uuid.UUID  `json:"reporter_id"`
+	ChirpID        *uuid.UUID `json:"chirp_id"`
+	AboutChirp     bool       `json:"about_chirp"`
+	ReportedUserID uuid.UUID  `json:"reported_user_id"`
+	Reason         string     `json:"reason"`
+	Details        string     `json:"details"`
+	Status         string     `json:"status"`
+	AssignedTo     *uuid.UUID `json:"assigned_to"`
+	Resolution     *string    `json:"resolution"`
+	ResolvedBy     *uuid.UUID `json:"resolved_by"`
+	ResolvedAt     *time.Time `json:"resolved_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+func reportFromDB(report database.Report) Report {
+	response := Report{
+		ID:             report.ID,
+		ReporterID:     report.ReporterID,
+		AboutChirp:     report.AboutChirp,
+		ReportedUserID: report.ReportedUserID,
+		Reason:         report.Reason,
+		Details:        report.Details,
+		Status:         report.Status,
+		CreatedAt:      report.CreatedAt,
+		UpdatedAt:      report.UpdatedAt,
+	}
+	if report.ChirpID.Valid {
+		response.ChirpID = &report.ChirpID.UUID
+	}
+	if report.AssignedTo.Valid {
+		response.AssignedTo = &report.AssignedTo.UUID
+	}
+	if report.Resolution.Valid {
+		response.Resolution = &report.Resolution.String
+	}
+	if report.ResolvedBy.Valid {
+		response.ResolvedBy = &report.ResolvedBy.UUID
+	}
+	if report.ResolvedAt.Valid {
+		response.ResolvedAt = &report.ResolvedAt.Time
+	}
+	return response
+}
+
+type reportParameters struct {
+	Reason  string `json:"reason"`
+	Details string `json:"details"`
+}
+
+func (p reportParameters) validate() error {
+	if !reportReasons[p.Reason] {
+		return errors.New("unknown report reason")
+	}
+	if utf8.RuneCountInString(p.Details) > maxReportDetailsLength {
+		return errors.New("details must be at most 1000 characters")
+	}
+	return nil
+}
+
+// report_chirp files a report against a chirp and its author. Reporting the
+// same chirp again while the reporter's earlier report is still open updates
+// that report instead of adding another; once it is resolved, a new report is
+// filed. Reporting a rechirp reports the original.
+func (cfg *apiConfig) report_chirp(w http.ResponseWriter, req *http.Request) {
+	userID, ok := cfg.authenticate(w, req)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Bad ID", err)
+		return
+	}
+
+	decoder := json.NewDecoder(req.Body)
+	params := reportParameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+	if err := params.validate(); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	chirp, err := cfg.originalChirp(req.Context(), id, userID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Not found", err)
+		return
+	}
+	if chirp.UserID == userID {
+		respondWithError(w, http.StatusBadRequest, "Cannot report your own chirp", nil)
+		return
+	}
+
+	report, err := cfg.db.CreateChirpReport(req.Context(), database.CreateChirpReportParams{
+		ReporterID:     userID,
+		ChirpID:        uuid.NullUUID{UUID: chirp.ID, Valid: true},
+		ReportedUserID: chirp.UserID,
+		Reason:         params.Reason,
+		Details:        params.Details,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not create report", err)
+		return
+	}
+
+	responseWithJSON(w, http.StatusCreated, reportFromDB(report))
+}
+
+// report_user files a report against an account rather than a single chirp,
+// de-duplicated per reporter like report_chirp.
+func (cfg *apiConfig) report_user(w http.ResponseWriter, req *http.Request) {
+	userID, ok := cfg.authenticate(w, req)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(req.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Bad ID", err)
+		return
+	}
+	if id == userID {
+		respondWithError(w, http.StatusBadRequest, "Cannot report yourself", nil)
+		return
+	}
+
+	decoder := json.NewDecoder(req.Body)
+	params := reportParameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+	if err := params.validate(); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	if _, err := cfg.db.GetUser(req.Context(), id); err != nil {
+		respondWithError(w, http.StatusNotFound, "Not found", err)
+		return
+	}
+
+	report, err := cfg.db.CreateUserReport(req.Context(), database.CreateUserReportParams{
+		ReporterID:     userID,
+		ReportedUserID: id,
+		Reason:         params.Reason,
+		Details:        params.Details,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not create report", err)
+		return
+	}
+
+	responseWithJSON(w, http.StatusCreated, reportFromDB(report))
+}
+
+// get_reports lists reports for moderators, newest first. It accepts
+// ?status= (open by default), ?reason=, ?assigned_to= (a user ID, "me" or
+// "none") and the usual pagination parameters.
+func (cfg *apiConfig) get_reports(w http.ResponseWriter, req *http.Request) {
+	userID, ok := cfg.requireRole(w, req, roleModerator)
+	if !ok {
+		return
+	}
+
+	p, err := parsePage(req)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	query := req.URL.Query()
+	params := database.GetReportsParams{
+		Status:   reportStatusOpen,
+		Before:   p.Before,
+		BeforeID: p.BeforeID,
+		PageSize: p.Limit,
+	}
+
+	if status := query.Get("status"); status != "" {
+		if status != reportStatusOpen && status != reportStatusResolved {
+			respondWithError(w, http.StatusBadRequest, "Unknown status", nil)
+			return
+		}
+		params.Status = status
+	}
+
+	if reason := query.Get("reason"); reason != "" {
+		if !reportReasons[reason] {
+			respondWithError(w, http.StatusBadRequest, "Unknown report reason", nil)
+			return
+		}
+		params.Reason = sql.NullString{String: reason, Valid: true}
+	}
+
+	switch assignee := query.Get("assigned_to"); assignee {
+	case "":
+	case "none":
+		params.Unassigned = true
+	case "me":
+		params.AssignedTo = uuid.NullUUID{UUID: userID, Valid: true}
+	default:
+		id, err := uuid.Parse(assignee)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Bad assignee", err)
+			return
+		}
+		params.AssignedTo = uuid.NullUUID{UUID: id, Valid: true}
+	}
+
+	reports, err := cfg.db.GetReports(req.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve reports", err)
+		return
+	}
+
+	response := make([]Report, len(reports))
+	for i, report := range reports {
+		response[i] = reportFromDB(report)
+	}
+	responseWithJSON(w, http.StatusOK, response)
+}
+
+// get_report returns a single report together with the moderation actions
+// taken on it.
+func (cfg *apiConfig) get_report(w http.ResponseWriter, req *http.Request) {
+	if _, ok := cfg.requireRole(w, req, roleModerator); !ok {
+		return
+	}
+
+	type actionS struct {
+		ID          uuid.UUID  `json:"id"`
+		ModeratorID *uuid.UUID `json:"moderator_id"`
+		Action      string     `json:"action"`
+		Note        string     `json:"note"`
+		CreatedAt   time.Time  `json:"created_at"`
+	}
+
+	type successS struct {
+		Report
+		Actions []actionS `json:"actions"`
+	}
+
+	id, err := uuid.Parse(req.PathValue("reportID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Bad ID", err)
+		return
+	}
+
+	report, err := cfg.db.GetReport(req.Context(), id)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Not found", err)
+		return
+	}
+
+	actions, err := cfg.db.GetModerationActionsByReport(req.Context(), uuid.NullUUID{UUID: id, Valid: true})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve actions", err)
+		return
+	}
+
+	response := successS{Report: reportFromDB(report), Actions: make([]actionS, len(actions))}
+	for i, action := range actions {
+		response.Actions[i] = actionS{
+			ID:        action.ID,
+			Action:    action.Action,
+			Note:      action.Note,
+			CreatedAt: action.CreatedAt,
+		}
+		if action.ModeratorID.Valid {
+			response.Actions[i].ModeratorID = &action.ModeratorID.UUID
+		}
+	}
+	responseWithJSON(w, http.StatusOK, response)
+}
+
+// assign_report assigns an open report to a moderator, the caller unless
+// assignee_id is given.
+func (cfg *apiConfig) assign_report(w http.ResponseWriter, req *http.Request) {
+	userID, ok := cfg.requireRole(w, req, roleModerator)
+	if !ok {
+		return
+	}
+
+	type parameters struct {
+		AssigneeID *uuid.UUID `json:"assignee_id"`
+	}
+
+	id, err := uuid.Parse(req.PathValue("reportID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Bad ID", err)
+		return
+	}
+
+	decoder := json.NewDecoder(req.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	assignee := userID
+	if params.AssigneeID != nil {
+		assignee = *params.AssigneeID
+		role, err := cfg.db.GetUserRole(req.Context(), assignee)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Unknown assignee", err)
+			return
+		}
+		if roleRank[role] < roleRank[roleModerator] {
+			respondWithError(w, http.StatusBadRequest, "Assignee is not a moderator", nil)
+			return
+		}
+	}
+
+	tx, err := cfg.dbConn.BeginTx(req.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not assign report", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	report, err := qtx.AssignReport(req.Context(), database.AssignReportParams{
+		AssignedTo: uuid.NullUUID{UUID: assignee, Valid: true},
+		ID:         id,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "No open report with that ID", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not assign report", err)
+		return
+	}
+
+	_, err = qtx.CreateModerationAction(req.Context(), database.CreateModerationActionParams{
+		ModeratorID: uuid.NullUUID{UUID: userID, Valid: true},
+		Action:      "assign",
+		ReportID:    uuid.NullUUID{UUID: report.ID, Valid: true},
+		UserID:      uuid.NullUUID{UUID: assignee, Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not record action", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not assign report", err)
+		return
+	}
+
+	responseWithJSON(w, http.StatusOK, reportFromDB(report))
+}
+
+// resolve_report closes an open report with one of the resolutions, along
+// with every other open report on the same chirp, or on the same user for
+// reports about a user, so one incident is handled once. Hiding a chirp and
+// suspending its author both notify the author. Staff cannot be suspended
+// through a report.
+func (cfg *apiConfig) resolve_report(w http.ResponseWriter, req *http.Request) {
+	userID, ok := cfg.requireRole(w, req, roleModerator)
+	if !ok {
+		return
+	}
+
+	type parameters struct {
+		Action     string `json:"action"`
+		Note       string `json:"note"`
+		SuspendFor string `json:"suspend_for"`
+	}
+
+	id, err := uuid.Parse(req.PathValue("reportID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Bad ID", err)
+		return
+	}
+
+	decoder := json.NewDecoder(req.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	suspension := defaultSuspension
+	switch params.Action {
+	case resolutionDismiss, resolutionHideChirp:
+	case resolutionSuspendAuthor:
+		if params.SuspendFor != "" {
+			suspension, err = time.ParseDuration(params.SuspendFor)
+			if err != nil || suspension <= 0 {
+				respondWithError(w, http.StatusBadRequest, "Bad suspension duration", err)
+				return
+			}
+		}
+	default:
+		respondWithError(w, http.StatusBadRequest, "Unknown action", nil)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(req.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not resolve report", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	report, err := qtx.ResolveReport(req.Context(), database.ResolveReportParams{
+		Resolution: sql.NullString{String: params.Action, Valid: true},
+		ResolvedBy: uuid.NullUUID{UUID: userID, Valid: true},
+		ID:         id,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "No open report with that ID", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not resolve report", err)
+		return
+	}
+
+	err = qtx.ResolveDuplicateReports(req.Context(), database.ResolveDuplicateReportsParams{
+		Resolution:     report.Resolution,
+		ResolvedBy:     report.ResolvedBy,
+		ID:             report.ID,
+		AboutChirp:     report.AboutChirp,
+		ChirpID:        report.ChirpID,
+		ReportedUserID: report.ReportedUserID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not resolve report", err)
+		return
+	}
+
+	action := database.CreateModerationActionParams{
+		ModeratorID: uuid.NullUUID{UUID: userID, Valid: true},
+		Action:      params.Action,
+		ReportID:    uuid.NullUUID{UUID: report.ID, Valid: true},
+		ChirpID:     report.ChirpID,
+		UserID:      uuid.NullUUID{UUID: report.ReportedUserID, Valid: true},
+		Note:        params.Note,
+	}
+
+	switch params.Action {
+	case resolutionHideChirp:
+		if !report.AboutChirp {
+			respondWithError(w, http.StatusBadRequest, "Report is not about a chirp", nil)
+			return
+		}
+		if !report.ChirpID.Valid {
+			respondWithError(w, http.StatusConflict, "Chirp no longer exists", nil)
+			return
+		}
+		if _, err := qtx.HideChirp(req.Context(), report.ChirpID.UUID); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Could not hide chirp", err)
+			return
+		}
+		err = notify(req.Context(), qtx, Notification{
+			UserID:  report.ReportedUserID,
+			Kind:    NotificationChirpModerated,
+			ChirpID: report.ChirpID,
+			Data:    map[string]string{"action": "hidden", "reason": report.Reason},
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Could not notify author", err)
+			return
+		}
+	case resolutionSuspendAuthor:
+		role, err := qtx.GetUserRole(req.Context(), report.ReportedUserID)
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusConflict, "User no longer exists", err)
+			return
+		}
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Could not suspend user", err)
+			return
+		}
+		if roleRank[role] >= roleRank[roleModerator] {
+			respondWithError(w, http.StatusForbidden, "Insufficient permissions", nil)
+			return
+		}
+		until := time.Now().UTC().Add(suspension)
+		if _, err := suspendUser(req.Context(), qtx, report.ReportedUserID, until, report.Reason); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Could not suspend user", err)
+			return
+		}
+	}
+
+	if _, err := qtx.CreateModerationAction(req.Context(), action); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not record action", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not resolve report", err)
+		return
+	}
+
+	responseWithJSON(w, http.StatusOK, reportFromDB(report))
+}
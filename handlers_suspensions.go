@@ -0,0 +1,248 @@
+package main
+
+import (
+	"chirpy/internal/database"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// suspendUser suspends userID until the given time, revokes their refresh
+// tokens and tells them why. Access JWTs stop working on their next use, see
+// authenticate. It reports false if there is no such user.
+func suspendUser(ctx context.Context, q *database.Queries, userID uuid.UUID, until time.Time, reason string) (bool, error) {
+	suspended, err := q.SuspendUser(ctx, database.SuspendUserParams{
+		SuspendedUntil: sql.NullTime{Time: until, Valid: true},
+		ID:             userID,
+	})
+	if err != nil || suspended == 0 {
+		return false, err
+	}
+
+	if err := q.RevokeUserRefreshTokens(ctx, userID); err != nil {
+		return false, err
+	}
+
+	err = notify(ctx, q, Notification{
+		UserID: userID,
+		Kind:   NotificationAccountSuspended,
+		Data:   map[string]any{"reason": reason, "suspended_until": until},
+	})
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// restrictionTarget parses the user ID in the path and checks that it may be
+// restricted by someone holding role: staff can only be restricted by a
+// higher role, so moderators can suspend users and admins can ban
+// moderators. On failure the error response has already been written.
+func (cfg *apiConfig) restrictionTarget(w http.ResponseWriter, req *http.Request, role string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(req.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Bad ID", err)
+		return uuid.Nil, false
+	}
+
+	targetRole, err := cfg.db.GetUserRole(req.Context(), id)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Not found", err)
+		return uuid.Nil, false
+	}
+	if roleRank[targetRole] >= roleRank[role] {
+		respondWithError(w, http.StatusForbidden, "Insufficient permissions", nil)
+		return uuid.Nil, false
+	}
+
+	return id, true
+}
+
+// recordUserAction runs apply and records it as a moderation action on
+// userID in one transaction. apply reports false if the user does not exist.
+func (cfg *apiConfig) recordUserAction(ctx context.Context, moderatorID, userID uuid.UUID, action, note string, apply func(q *database.Queries) (bool, error)) (bool, error) {
+	tx, err := cfg.dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	found, err := apply(qtx)
+	if err != nil || !found {
+		return false, err
+	}
+
+	_, err = qtx.CreateModerationAction(ctx, database.CreateModerationActionParams{
+		ModeratorID: uuid.NullUUID{UUID: moderatorID, Valid: true},
+		Action:      action,
+		UserID:      uuid.NullUUID{UUID: userID, Valid: true},
+		Note:        note,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+func (cfg *apiConfig) suspend_user(w http.ResponseWriter, req *http.Request) {
+	moderatorID, ok := cfg.requireRole(w, req, roleModerator)
+	if !ok {
+		return
+	}
+	userID, ok := cfg.restrictionTarget(w, req, roleModerator)
+	if !ok {
+		return
+	}
+
+	type parameters struct {
+		Until    *time.Time `json:"until"`
+		Duration string     `json:"duration"`
+		Reason   string     `json:"reason"`
+	}
+
+	type successS struct {
+		SuspendedUntil time.Time `json:"suspended_until"`
+	}
+
+	decoder := json.NewDecoder(req.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	now := time.Now().UTC()
+	until := now.Add(defaultSuspension)
+	switch {
+	case params.Until != nil && params.Duration != "":
+		respondWithError(w, http.StatusBadRequest, "Give either until or duration, not both", nil)
+		return
+	case params.Until != nil:
+		until = params.Until.UTC()
+	case params.Duration != "":
+		duration, err := time.ParseDuration(params.Duration)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Bad suspension duration", err)
+			return
+		}
+		until = now.Add(duration)
+	}
+	if !until.After(now) {
+		respondWithError(w, http.StatusBadRequest, "Suspension must end in the future", nil)
+		return
+	}
+
+	found, err := cfg.recordUserAction(req.Context(), moderatorID, userID, "suspend", params.Reason, func(q *database.Queries) (bool, error) {
+		return suspendUser(req.Context(), q, userID, until, params.Reason)
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not suspend user", err)
+		return
+	}
+	if !found {
+		respondWithError(w, http.StatusNotFound, "Not found", nil)
+		return
+	}
+
+	responseWithJSON(w, http.StatusOK, successS{SuspendedUntil: until})
+}
+
+func (cfg *apiConfig) unsuspend_user(w http.ResponseWriter, req *http.Request) {
+	moderatorID, ok := cfg.requireRole(w, req, roleModerator)
+	if !ok {
+		return
+	}
+	userID, ok := cfg.restrictionTarget(w, req, roleModerator)
+	if !ok {
+		return
+	}
+
+	found, err := cfg.recordUserAction(req.Context(), moderatorID, userID, "unsuspend", "", func(q *database.Queries) (bool, error) {
+		lifted, err := q.SuspendUser(req.Context(), database.SuspendUserParams{ID: userID})
+		return lifted > 0, err
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not lift suspension", err)
+		return
+	}
+	if !found {
+		respondWithError(w, http.StatusNotFound, "Not found", nil)
+		return
+	}
+
+	responseWithJSON(w, http.StatusNoContent, nil)
+}
+
+func (cfg *apiConfig) ban_user(w http.ResponseWriter, req *http.Request) {
+	adminID, ok := cfg.requireRole(w, req, roleAdmin)
+	if !ok {
+		return
+	}
+	userID, ok := cfg.restrictionTarget(w, req, roleAdmin)
+	if !ok {
+		return
+	}
+
+	type parameters struct {
+		Reason string `json:"reason"`
+	}
+
+	decoder := json.NewDecoder(req.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	found, err := cfg.recordUserAction(req.Context(), adminID, userID, "ban", params.Reason, func(q *database.Queries) (bool, error) {
+		banned, err := q.BanUser(req.Context(), userID)
+		if err != nil || banned == 0 {
+			return false, err
+		}
+		return true, q.RevokeUserRefreshTokens(req.Context(), userID)
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not ban user", err)
+		return
+	}
+	if !found {
+		respondWithError(w, http.StatusNotFound, "Not found", nil)
+		return
+	}
+
+	responseWithJSON(w, http.StatusNoContent, nil)
+}
+
+func (cfg *apiConfig) unban_user(w http.ResponseWriter, req *http.Request) {
+	adminID, ok := cfg.requireRole(w, req, roleAdmin)
+	if !ok {
+		return
+	}
+	userID, ok := cfg.restrictionTarget(w, req, roleAdmin)
+	if !ok {
+		return
+	}
+
+	found, err := cfg.recordUserAction(req.Context(), adminID, userID, "unban", "", func(q *database.Queries) (bool, error) {
+		unbanned, err := q.UnbanUser(req.Context(), userID)
+		return unbanned > 0, err
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not lift ban", err)
+		return
+	}
+	if !found {
+		respondWithError(w, http.StatusNotFound, "Not found", nil)
+		return
+	}
+
+	responseWithJSON(w, http.StatusNoContent, nil)
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"chirpy/internal/auth"
+	"chirpy/internal/database"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// trashRetention is how long soft-deleted chirps and users can be restored
+// before the purge job removes them for good.
+const trashRetention = 30 * 24 * time.Hour
+
+func trashCutoff() time.Time {
+	return time.Now().UTC().Add(-trashRetention)
+}
+
+func (cfg *apiConfig) get_trash(w http.ResponseWriter, req *http.Request) {
+	userID, ok := cfg.authenticate(w, req)
+	if !ok {
+		return
+	}
+
+	type trashedS struct {
+		DeletedAt time.Time `json:"deleted_at"`
+		PurgeAt   time.Time `json:"purge_at"`
+		Chirp     Chirp     `json:"chirp"`
+	}
+
+	chirps, err := cfg.db.GetDeletedChirps(req.Context(), database.GetDeletedChirpsParams{
+		UserID: userID,
+		Cutoff: trashCutoff(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve trash", err)
+		return
+	}
+
+	responseChirps, err := cfg.buildChirps(req.Context(), chirps, userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve trash", err)
+		return
+	}
+
+	response := make([]trashedS, len(chirps))
+	for i, chirp := range chirps {
+		response[i] = trashedS{
+			DeletedAt: chirp.DeletedAt.Time,
+			PurgeAt:   chirp.DeletedAt.Time.Add(trashRetention),
+			Chirp:     responseChirps[i],
+		}
+	}
+	responseWithJSON(w, http.StatusOK, response)
+}
+
+func (cfg *apiConfig) restore_chirp(w http.ResponseWriter, req *http.Request) {
+	userID, ok := cfg.authenticate(w, req)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Bad ID", err)
+		return
+	}
+
+	chirp, err := cfg.db.RestoreChirp(req.Context(), database.RestoreChirpParams{
+		ID:     id,
+		UserID: userID,
+		Cutoff: trashCutoff(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Not found in trash", err)
+		return
+	}
+
+	response, err := cfg.buildChirp(req.Context(), chirp, userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not build chirp", err)
+		return
+	}
+	responseWithJSON(w, http.StatusOK, response)
+}
+
+func (cfg *apiConfig) delete_user(w http.ResponseWriter, req *http.Request) {
+	userID, ok := cfg.authenticate(w, req)
+	if !ok {
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(req.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not delete user", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	if err := qtx.SoftDeleteUser(req.Context(), userID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not delete user", err)
+		return
+	}
+	if err := qtx.RevokeUserRefreshTokens(req.Context(), userID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not revoke refresh tokens", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not delete user", err)
+		return
+	}
+
+	responseWithJSON(w, http.StatusNoContent, nil)
+}
+
+func (cfg *apiConfig) restore_user(w http.ResponseWriter, req *http.Request) {
+	type parameters struct {
+		Email    string `json:"email"`
+		Password string `json:"password"`
+	}
+
+	type successS struct {
+		ID        uuid.UUID `json:"id"`
+		CreatedAt time.Time `json:"created_at"`
+		UpdatedAt time.Time `json:"updated_at"`
+		Email     string    `json:"email"`
+		Handle    string    `json:"handle,omitempty"`
+	}
+
+	decoder := json.NewDecoder(req.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	user, err := cfg.db.GetDeletedUserByEmail(req.Context(), database.GetDeletedUserByEmailParams{
+		Email:  params.Email,
+		Cutoff: trashCutoff(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Incorrect email or password", nil)
+		return
+	}
+
+	passErr := auth.CheckPasswordHash(params.Password, user.HashedPassword)
+	if passErr != nil {
+		respondWithError(w, http.StatusUnauthorized, "Incorrect email or password", nil)
+		return
+	}
+
+	user, err = cfg.db.RestoreUser(req.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not restore user", err)
+		return
+	}
+
+	responseWithJSON(w, http.StatusOK, successS{
+		ID:        user.ID,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+		Email:     user.Email,
+		Handle:    user.Handle.String,
+	})
+}
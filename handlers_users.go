@@ -3,8 +3,10 @@ package main
 import (
 	"chirpy/internal/auth"
 	"chirpy/internal/database"
+	"chirpy/internal/entities"
 	"database/sql"
 	"encoding/json"
+	"log"
 	"net/http"
 	"time"
 
@@ -15,6 +17,7 @@ func (cfg *apiConfig) create_user(w http.ResponseWriter, req *http.Request) {
 	type parameters struct {
 		Email    string `json:"email"`
 		Password string `json:"password"`
+		Handle   string `json:"handle"`
 	}
 
 	type successS struct {
@@ -22,6 +25,7 @@ func (cfg *apiConfig) create_user(w http.ResponseWriter, req *http.Request) {
 		CreatedAt time.Time `json:"created_at"`
 		UpdatedAt time.Time `json:"updated_at"`
 		Email     string    `json:"email"`
+		Handle    string    `json:"handle,omitempty"`
 	}
 
 	decoder := json.NewDecoder(req.Body)
@@ -33,14 +37,27 @@ func (cfg *apiConfig) create_user(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	handle := sql.NullString{}
+	if params.Handle != "" {
+		handle.String = entities.NormalizeHandle(params.Handle)
+		handle.Valid = true
+		if !entities.ValidHandle(handle.String) {
+			respondWithError(w, http.StatusBadRequest, "Handles must be 1-30 letters, digits or underscores", nil)
+			return
+		}
+	}
+
 	hashed, err := auth.HashPassword(params.Password)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Something went wrong", err)
 		return
 	}
 
-	user, err := cfg.db.CreateUser(req.Context(), database.CreateUserParams{Email: params.Email, HashedPassword: hashed})
-
+	user, err := cfg.db.CreateUser(req.Context(), database.CreateUserParams{Email: params.Email, HashedPassword: hashed, Handle: handle})
+	if isUniqueViolation(err) {
+		respondWithError(w, http.StatusConflict, "Email or handle already taken", nil)
+		return
+	}
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Something went wrong creating user", err)
 		return
@@ -51,9 +68,113 @@ func (cfg *apiConfig) create_user(w http.ResponseWriter, req *http.Request) {
 		CreatedAt: user.CreatedAt,
 		UpdatedAt: user.UpdatedAt,
 		Email:     user.Email,
+		Handle:    user.Handle.String,
 	})
 }
 
+func (cfg *apiConfig) update_user(w http.ResponseWriter, req *http.Request) {
+	userID, ok := cfg.authenticate(w, req)
+	if !ok {
+		return
+	}
+
+	type parameters struct {
+		Handle string `json:"handle"`
+	}
+
+	type successS struct {
+		ID         uuid.UUID         `json:"id"`
+		CreatedAt  time.Time         `json:"created_at"`
+		UpdatedAt  time.Time         `json:"updated_at"`
+		Email      string            `json:"email"`
+		Handle     string            `json:"handle,omitempty"`
+		AvatarURLs map[string]string `json:"avatar_urls,omitempty"`
+	}
+
+	decoder := json.NewDecoder(req.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	handle := entities.NormalizeHandle(params.Handle)
+	if !entities.ValidHandle(handle) {
+		respondWithError(w, http.StatusBadRequest, "Handles must be 1-30 letters, digits or underscores", nil)
+		return
+	}
+
+	user, err := cfg.db.UpdateUserHandle(req.Context(), database.UpdateUserHandleParams{
+		Handle: sql.NullString{String: handle, Valid: true},
+		ID:     userID,
+	})
+	if isUniqueViolation(err) {
+		respondWithError(w, http.StatusConflict, "Handle already taken", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update user", err)
+		return
+	}
+
+	responseWithJSON(w, http.StatusOK, successS{
+		ID:         user.ID,
+		CreatedAt:  user.CreatedAt,
+		UpdatedAt:  user.UpdatedAt,
+		Email:      user.Email,
+		Handle:     user.Handle.String,
+		AvatarURLs: avatarURLs(user.Avatar),
+	})
+}
+
+// preferences is the JSON representation of a user's display preferences.
+// With ExpandSensitive set, chirps served to the user are never collapsed.
+type preferences struct {
+	ExpandSensitive bool `json:"expand_sensitive"`
+}
+
+func (cfg *apiConfig) get_preferences(w http.ResponseWriter, req *http.Request) {
+	userID, ok := cfg.authenticate(w, req)
+	if !ok {
+		return
+	}
+
+	user, err := cfg.db.GetUser(req.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't retrieve preferences", err)
+		return
+	}
+
+	responseWithJSON(w, http.StatusOK, preferences{ExpandSensitive: user.ExpandSensitive})
+}
+
+func (cfg *apiConfig) update_preferences(w http.ResponseWriter, req *http.Request) {
+	userID, ok := cfg.authenticate(w, req)
+	if !ok {
+		return
+	}
+
+	decoder := json.NewDecoder(req.Body)
+	params := preferences{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	user, err := cfg.db.UpdateUserPreferences(req.Context(), database.UpdateUserPreferencesParams{
+		ExpandSensitive: params.ExpandSensitive,
+		ID:              userID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't update preferences", err)
+		return
+	}
+
+	responseWithJSON(w, http.StatusOK, preferences{ExpandSensitive: user.ExpandSensitive})
+}
+
 func (cfg *apiConfig) login_user(w http.ResponseWriter, req *http.Request) {
 	type parameters struct {
 		Email    string `json:"email"`
@@ -61,12 +182,14 @@ func (cfg *apiConfig) login_user(w http.ResponseWriter, req *http.Request) {
 	}
 
 	type successS struct {
-		ID           uuid.UUID `json:"id"`
-		CreatedAt    time.Time `json:"created_at"`
-		UpdatedAt    time.Time `json:"updated_at"`
-		Email        string    `json:"email"`
-		Token        string    `json:"token"`
-		RefreshToken string    `json:"refresh_token"`
+		ID           uuid.UUID         `json:"id"`
+		CreatedAt    time.Time         `json:"created_at"`
+		UpdatedAt    time.Time         `json:"updated_at"`
+		Email        string            `json:"email"`
+		Handle       string            `json:"handle,omitempty"`
+		AvatarURLs   map[string]string `json:"avatar_urls,omitempty"`
+		Token        string            `json:"token"`
+		RefreshToken string            `json:"refresh_token"`
 	}
 
 	decoder := json.NewDecoder(req.Body)
@@ -89,6 +212,11 @@ func (cfg *apiConfig) login_user(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	if err := checkAccess(user.BannedAt, user.SuspendedUntil); err != nil {
+		respondWithError(w, http.StatusForbidden, err.Error(), nil)
+		return
+	}
+
 	token, err := auth.MakeJWT(user.ID, cfg.secret, time.Hour)
 	// fmt.Printf("Token created: %s\n", token)
 	if err != nil {
@@ -115,11 +243,25 @@ func (cfg *apiConfig) login_user(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	err = notify(req.Context(), cfg.db, Notification{
+		UserID: user.ID,
+		Kind:   NotificationNewLogin,
+		Data: map[string]string{
+			"remote_addr": req.RemoteAddr,
+			"user_agent":  req.UserAgent(),
+		},
+	})
+	if err != nil {
+		log.Printf("Could not send login notification: %s", err)
+	}
+
 	responseWithJSON(w, 200, successS{
 		ID:           user.ID,
 		CreatedAt:    user.CreatedAt,
 		UpdatedAt:    user.UpdatedAt,
 		Email:        user.Email,
+		Handle:       user.Handle.String,
+		AvatarURLs:   avatarURLs(user.Avatar),
 		Token:        token,
 		RefreshToken: refreshToken,
 	})
@@ -160,7 +302,7 @@ func (cfg *apiConfig) revoke_refresh(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	_, err := cfg.db.RevokeRefreshToken(req.Context(), database.RevokeRefreshTokenParams{
+	revoked, err := cfg.db.RevokeRefreshToken(req.Context(), database.RevokeRefreshTokenParams{
 		Token: headerToken,
 		RevokedAt: sql.NullTime{
 			Time:  time.Now().UTC(),
@@ -172,5 +314,17 @@ func (cfg *apiConfig) revoke_refresh(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	err = notify(req.Context(), cfg.db, Notification{
+		UserID: revoked.UserID,
+		Kind:   NotificationRefreshTokenRevoked,
+		Data: map[string]string{
+			"remote_addr": req.RemoteAddr,
+			"user_agent":  req.UserAgent(),
+		},
+	})
+	if err != nil {
+		log.Printf("Could not send revocation notification: %s", err)
+	}
+
 	responseWithJSON(w, 204, nil)
 }
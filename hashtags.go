@@ -0,0 +1,150 @@
+package main
+
+import (
+	"chirpy/internal/database"
+	"chirpy/internal/entities"
+	"chirpy/internal/trending"
+	"context"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	trendingWindow   = 24 * time.Hour
+	trendingHalfLife = 6 * time.Hour
+	trendingInterval = 5 * time.Minute
+	trendingLimit    = 20
+)
+
+// trendingTags holds the most recently computed trending ranking. It is
+// refreshed in the background so requests never pay for the aggregation.
+type trendingTags struct {
+	mu         sync.RWMutex
+	scores     []trending.Score
+	computedAt time.Time
+}
+
+func (t *trendingTags) get() ([]trending.Score, time.Time) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.scores, t.computedAt
+}
+
+func (t *trendingTags) set(scores []trending.Score, computedAt time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.scores = scores
+	t.computedAt = computedAt
+}
+
+// saveChirpHashtags links chirp to every hashtag in its body, creating
+// hashtags that have not been seen before.
+func saveChirpHashtags(ctx context.Context, q *database.Queries, chirp database.Chirp) error {
+	for _, tag := range entities.UniqueTags(entities.Hashtags(chirp.Body)) {
+		hashtag, err := q.UpsertHashtag(ctx, tag)
+		if err != nil {
+			return err
+		}
+		err = q.AddChirpHashtag(ctx, database.AddChirpHashtagParams{ChirpID: chirp.ID, HashtagID: hashtag.ID})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (cfg *apiConfig) refreshTrending(ctx context.Context) error {
+	now := time.Now().UTC()
+	rows, err := cfg.db.GetHashtagUsage(ctx, now.Add(-trendingWindow))
+	if err != nil {
+		return err
+	}
+
+	usage := make([]trending.Usage, len(rows))
+	for i, row := range rows {
+		usage[i] = trending.Usage{Tag: row.Tag, Bucket: row.Bucket, Count: row.Uses}
+	}
+
+	cfg.trending.set(trending.Rank(usage, now, trendingHalfLife, trendingLimit), now)
+	return nil
+}
+
+// runTrending recomputes trending hashtags every trendingInterval until ctx
+// is cancelled.
+func (cfg *apiConfig) runTrending(ctx context.Context) {
+	ticker := time.NewTicker(trendingInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := cfg.refreshTrending(ctx); err != nil {
+			log.Printf("Could not refresh trending hashtags: %s", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (cfg *apiConfig) get_hashtag_chirps(w http.ResponseWriter, req *http.Request) {
+	viewerID, ok := cfg.viewer(w, req)
+	if !ok {
+		return
+	}
+
+	tag := entities.NormalizeHashtag(req.PathValue("tag"))
+	if tag == "" {
+		respondWithError(w, http.StatusBadRequest, "Bad hashtag", nil)
+		return
+	}
+
+	hide, err := hideSensitive(req)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	chirps, err := cfg.db.GetChirpsByHashtag(req.Context(), database.GetChirpsByHashtagParams{
+		Tag:           tag,
+		HideSensitive: hide,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve chirps", err)
+		return
+	}
+
+	responseChirps, err := cfg.buildChirps(req.Context(), chirps, viewerID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve chirps", err)
+		return
+	}
+	responseWithJSON(w, http.StatusOK, responseChirps)
+}
+
+func (cfg *apiConfig) get_trending(w http.ResponseWriter, req *http.Request) {
+	type trendingTag struct {
+		Tag   string  `json:"tag"`
+		Score float64 `json:"score"`
+		Uses  int64   `json:"uses"`
+	}
+
+	type successS struct {
+		ComputedAt time.Time     `json:"computed_at"`
+		Tags       []trendingTag `json:"tags"`
+	}
+
+	scores, computedAt := cfg.trending.get()
+	tags := make([]trendingTag, len(scores))
+	for i, score := range scores {
+		tags[i] = trendingTag{Tag: score.Tag, Score: score.Score, Uses: score.Uses}
+	}
+
+	responseWithJSON(w, http.StatusOK, successS{
+		ComputedAt: computedAt,
+		Tags:       tags,
+	})
+}
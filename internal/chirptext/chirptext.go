@@ -0,0 +1,35 @@
+// Package chirptext normalises chirp bodies and measures their length the
+// way a reader would count it.
+package chirptext
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+
+	"github.com/rivo/uniseg"
+	"golang.org/x/text/unicode/norm"
+)
+
+var ErrEmpty = errors.New("chirp is empty")
+
+// Normalize converts body to NFC and trims surrounding whitespace. It returns
+// ErrEmpty if nothing but whitespace, control or formatting characters
+// remain.
+func Normalize(body string) (string, error) {
+	body = strings.TrimSpace(norm.NFC.String(body))
+
+	for _, r := range body {
+		if !unicode.IsControl(r) && !unicode.Is(unicode.Cf, r) && !unicode.IsSpace(r) {
+			return body, nil
+		}
+	}
+	return "", ErrEmpty
+}
+
+// Length counts the user-perceived characters (extended grapheme clusters)
+// in body, so an emoji with skin tone or a letter with combining accents
+// counts as one.
+func Length(body string) int {
+	return uniseg.GraphemeClusterCount(body)
+}
@@ -0,0 +1,96 @@
+package chirptext
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    string
+		wantErr error
+	}{
+		{
+			name: "Trims whitespace",
+			body: "  hello world \n",
+			want: "hello world",
+		},
+		{
+			name: "Composes combining characters",
+			body: "cafe\u0301",
+			want: "caf\u00e9",
+		},
+		{
+			name:    "Empty body",
+			body:    "",
+			wantErr: ErrEmpty,
+		},
+		{
+			name:    "Whitespace only",
+			body:    " \t\n ",
+			wantErr: ErrEmpty,
+		},
+		{
+			name:    "Control and format characters only",
+			body:    "\u0007\u200b\u0000",
+			wantErr: ErrEmpty,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Normalize(tt.body)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Normalize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Normalize() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLength(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{
+			name: "ASCII",
+			body: "hello",
+			want: 5,
+		},
+		{
+			name: "Emoji count once each",
+			body: strings.Repeat("😀", 40),
+			want: 40,
+		},
+		{
+			name: "Emoji with skin tone modifier",
+			body: "👍🏽",
+			want: 1,
+		},
+		{
+			name: "Family emoji joined with ZWJ",
+			body: "👨‍👩‍👧",
+			want: 1,
+		},
+		{
+			name: "Combining characters",
+			body: "e\u0301e\u0301",
+			want: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Length(tt.body); got != tt.want {
+				t.Errorf("Length() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
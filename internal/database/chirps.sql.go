@@ -7,26 +7,136 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const countPinnedChirps = `-- name: CountPinnedChirps :one
+SELECT count(*) FROM chirps
+WHERE user_id = $1
+AND pinned_at IS NOT NULL
+AND chirp_live(chirps)
+`
+
+func (q *Queries) CountPinnedChirps(ctx context.Context, userID uuid.UUID) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countPinnedChirps, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countRecentChirps = `-- name: CountRecentChirps :one
+SELECT count(*) FROM chirps
+WHERE user_id = $1
+AND created_at > $2::timestamp
+AND id IS DISTINCT FROM $3::uuid
+`
+
+type CountRecentChirpsParams struct {
+	UserID    uuid.UUID
+	Since     time.Time
+	ExcludeID uuid.NullUUID
+}
+
+func (q *Queries) CountRecentChirps(ctx context.Context, arg CountRecentChirpsParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countRecentChirps, arg.UserID, arg.Since, arg.ExcludeID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createChirp = `-- name: CreateChirp :one
-INSERT INTO chirps (body, user_id)
+INSERT INTO chirps (body, user_id, quote_of, held_at, content_warning, sensitive, visibility, scheduled_for, expires_at)
 VALUES (
     $1,
-    $2
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9
 )
-RETURNING id, body, user_id, created_at, updated_at
+RETURNING id, body, user_id, created_at, updated_at, rechirp_of, quote_of, edited_at, deleted_at, hidden_at, held_at, content_warning, sensitive, visibility, pinned_at, scheduled_for, expires_at
 `
 
 type CreateChirpParams struct {
-	Body   string
-	UserID uuid.UUID
+	Body           string
+	UserID         uuid.UUID
+	QuoteOf        uuid.NullUUID
+	HeldAt         sql.NullTime
+	ContentWarning sql.NullString
+	Sensitive      bool
+	Visibility     string
+	ScheduledFor   sql.NullTime
+	ExpiresAt      sql.NullTime
 }
 
 func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID)
+	row := q.db.QueryRowContext(ctx, createChirp,
+		arg.Body,
+		arg.UserID,
+		arg.QuoteOf,
+		arg.HeldAt,
+		arg.ContentWarning,
+		arg.Sensitive,
+		arg.Visibility,
+		arg.ScheduledFor,
+		arg.ExpiresAt,
+	)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.Body,
+		&i.UserID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.RechirpOf,
+		&i.QuoteOf,
+		&i.EditedAt,
+		&i.DeletedAt,
+		&i.HiddenAt,
+		&i.HeldAt,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.Visibility,
+		&i.PinnedAt,
+		&i.ScheduledFor,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const createRechirp = `-- name: CreateRechirp :one
+INSERT INTO chirps (body, user_id, rechirp_of, visibility, expires_at)
+VALUES (
+    '',
+    $1,
+    $2,
+    $3,
+    $4
+)
+RETURNING id, body, user_id, created_at, updated_at, rechirp_of, quote_of, edited_at, deleted_at, hidden_at, held_at, content_warning, sensitive, visibility, pinned_at, scheduled_for, expires_at
+`
+
+type CreateRechirpParams struct {
+	UserID     uuid.UUID
+	RechirpOf  uuid.NullUUID
+	Visibility string
+	ExpiresAt  sql.NullTime
+}
+
+func (q *Queries) CreateRechirp(ctx context.Context, arg CreateRechirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, createRechirp,
+		arg.UserID,
+		arg.RechirpOf,
+		arg.Visibility,
+		arg.ExpiresAt,
+	)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -34,16 +144,131 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		&i.UserID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.RechirpOf,
+		&i.QuoteOf,
+		&i.EditedAt,
+		&i.DeletedAt,
+		&i.HiddenAt,
+		&i.HeldAt,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.Visibility,
+		&i.PinnedAt,
+		&i.ScheduledFor,
+		&i.ExpiresAt,
 	)
 	return i, err
 }
 
+const deleteExpiredChirps = `-- name: DeleteExpiredChirps :execrows
+DELETE FROM chirps
+WHERE id IN (
+    SELECT id FROM chirps
+    WHERE expires_at <= now()
+    LIMIT $1
+    FOR UPDATE SKIP LOCKED
+)
+`
+
+func (q *Queries) DeleteExpiredChirps(ctx context.Context, batchSize int32) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteExpiredChirps, batchSize)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteRechirp = `-- name: DeleteRechirp :execrows
+DELETE FROM chirps
+WHERE user_id = $1
+AND rechirp_of = $2
+`
+
+type DeleteRechirpParams struct {
+	UserID    uuid.UUID
+	RechirpOf uuid.NullUUID
+}
+
+func (q *Queries) DeleteRechirp(ctx context.Context, arg DeleteRechirpParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteRechirp, arg.UserID, arg.RechirpOf)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteScheduledChirp = `-- name: DeleteScheduledChirp :execrows
+DELETE FROM chirps
+WHERE id = $1
+AND user_id = $2
+AND scheduled_for IS NOT NULL
+`
+
+type DeleteScheduledChirpParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) DeleteScheduledChirp(ctx context.Context, arg DeleteScheduledChirpParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteScheduledChirp, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const getChirp = `-- name: GetChirp :one
-SELECT id, body, user_id, created_at, updated_at FROM chirps WHERE id = $1
+SELECT chirps.id, chirps.body, chirps.user_id, chirps.created_at, chirps.updated_at, chirps.rechirp_of, chirps.quote_of, chirps.edited_at, chirps.deleted_at, chirps.hidden_at, chirps.held_at, chirps.content_warning, chirps.sensitive, chirps.visibility, chirps.pinned_at, chirps.scheduled_for, chirps.expires_at FROM chirps
+WHERE chirps.id = $1
+AND chirp_live(chirps)
+AND chirp_readable(chirps, $2)
 `
 
-func (q *Queries) GetChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, getChirp, id)
+type GetChirpParams struct {
+	ID       uuid.UUID
+	ViewerID uuid.UUID
+}
+
+func (q *Queries) GetChirp(ctx context.Context, arg GetChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getChirp, arg.ID, arg.ViewerID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.Body,
+		&i.UserID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.RechirpOf,
+		&i.QuoteOf,
+		&i.EditedAt,
+		&i.DeletedAt,
+		&i.HiddenAt,
+		&i.HeldAt,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.Visibility,
+		&i.PinnedAt,
+		&i.ScheduledFor,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const getChirpForUpdate = `-- name: GetChirpForUpdate :one
+SELECT chirps.id, chirps.body, chirps.user_id, chirps.created_at, chirps.updated_at, chirps.rechirp_of, chirps.quote_of, chirps.edited_at, chirps.deleted_at, chirps.hidden_at, chirps.held_at, chirps.content_warning, chirps.sensitive, chirps.visibility, chirps.pinned_at, chirps.scheduled_for, chirps.expires_at FROM chirps
+WHERE chirps.id = $1
+AND chirp_live(chirps)
+AND chirp_readable(chirps, $2)
+FOR UPDATE
+`
+
+type GetChirpForUpdateParams struct {
+	ID       uuid.UUID
+	ViewerID uuid.UUID
+}
+
+func (q *Queries) GetChirpForUpdate(ctx context.Context, arg GetChirpForUpdateParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getChirpForUpdate, arg.ID, arg.ViewerID)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -51,16 +276,395 @@ func (q *Queries) GetChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
 		&i.UserID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.RechirpOf,
+		&i.QuoteOf,
+		&i.EditedAt,
+		&i.DeletedAt,
+		&i.HiddenAt,
+		&i.HeldAt,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.Visibility,
+		&i.PinnedAt,
+		&i.ScheduledFor,
+		&i.ExpiresAt,
 	)
 	return i, err
 }
 
 const getChirps = `-- name: GetChirps :many
-SELECT id, body, user_id, created_at, updated_at FROM chirps ORDER BY created_at ASC
+SELECT chirps.id, chirps.body, chirps.user_id, chirps.created_at, chirps.updated_at, chirps.rechirp_of, chirps.quote_of, chirps.edited_at, chirps.deleted_at, chirps.hidden_at, chirps.held_at, chirps.content_warning, chirps.sensitive, chirps.visibility, chirps.pinned_at, chirps.scheduled_for, chirps.expires_at FROM chirps
+WHERE chirp_live(chirps)
+AND chirps.visibility = 'public'
+AND NOT ($1::bool AND chirp_sensitive(chirps))
+ORDER BY chirps.created_at ASC
+`
+
+func (q *Queries) GetChirps(ctx context.Context, hideSensitive bool) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirps, hideSensitive)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.Body,
+			&i.UserID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.RechirpOf,
+			&i.QuoteOf,
+			&i.EditedAt,
+			&i.DeletedAt,
+			&i.HiddenAt,
+			&i.HeldAt,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.Visibility,
+			&i.PinnedAt,
+			&i.ScheduledFor,
+			&i.ExpiresAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpsByAuthor = `-- name: GetChirpsByAuthor :many
+SELECT chirps.id, chirps.body, chirps.user_id, chirps.created_at, chirps.updated_at, chirps.rechirp_of, chirps.quote_of, chirps.edited_at, chirps.deleted_at, chirps.hidden_at, chirps.held_at, chirps.content_warning, chirps.sensitive, chirps.visibility, chirps.pinned_at, chirps.scheduled_for, chirps.expires_at FROM chirps
+WHERE chirps.user_id = $1
+AND chirps.pinned_at IS NULL
+AND chirp_live(chirps)
+AND chirp_readable(chirps, $2)
+AND NOT ($3::bool AND chirp_sensitive(chirps))
+ORDER BY chirps.created_at ASC
+`
+
+type GetChirpsByAuthorParams struct {
+	AuthorID      uuid.UUID
+	ViewerID      uuid.UUID
+	HideSensitive bool
+}
+
+func (q *Queries) GetChirpsByAuthor(ctx context.Context, arg GetChirpsByAuthorParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByAuthor, arg.AuthorID, arg.ViewerID, arg.HideSensitive)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.Body,
+			&i.UserID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.RechirpOf,
+			&i.QuoteOf,
+			&i.EditedAt,
+			&i.DeletedAt,
+			&i.HiddenAt,
+			&i.HeldAt,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.Visibility,
+			&i.PinnedAt,
+			&i.ScheduledFor,
+			&i.ExpiresAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpsByIDs = `-- name: GetChirpsByIDs :many
+SELECT chirps.id, chirps.body, chirps.user_id, chirps.created_at, chirps.updated_at, chirps.rechirp_of, chirps.quote_of, chirps.edited_at, chirps.deleted_at, chirps.hidden_at, chirps.held_at, chirps.content_warning, chirps.sensitive, chirps.visibility, chirps.pinned_at, chirps.scheduled_for, chirps.expires_at FROM chirps
+WHERE chirps.id = ANY($1::uuid[])
+AND chirp_live(chirps)
+-- Only public and unlisted chirps can be rechirped or quoted.
+AND chirps.visibility IN ('public', 'unlisted')
+`
+
+func (q *Queries) GetChirpsByIDs(ctx context.Context, ids []uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByIDs, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.Body,
+			&i.UserID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.RechirpOf,
+			&i.QuoteOf,
+			&i.EditedAt,
+			&i.DeletedAt,
+			&i.HiddenAt,
+			&i.HeldAt,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.Visibility,
+			&i.PinnedAt,
+			&i.ScheduledFor,
+			&i.ExpiresAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDeletedChirps = `-- name: GetDeletedChirps :many
+SELECT id, body, user_id, created_at, updated_at, rechirp_of, quote_of, edited_at, deleted_at, hidden_at, held_at, content_warning, sensitive, visibility, pinned_at, scheduled_for, expires_at FROM chirps
+WHERE user_id = $1
+AND deleted_at > $2::timestamp
+AND (expires_at IS NULL OR expires_at > now())
+ORDER BY deleted_at DESC
+`
+
+type GetDeletedChirpsParams struct {
+	UserID uuid.UUID
+	Cutoff time.Time
+}
+
+func (q *Queries) GetDeletedChirps(ctx context.Context, arg GetDeletedChirpsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getDeletedChirps, arg.UserID, arg.Cutoff)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.Body,
+			&i.UserID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.RechirpOf,
+			&i.QuoteOf,
+			&i.EditedAt,
+			&i.DeletedAt,
+			&i.HiddenAt,
+			&i.HeldAt,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.Visibility,
+			&i.PinnedAt,
+			&i.ScheduledFor,
+			&i.ExpiresAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getHeldChirps = `-- name: GetHeldChirps :many
+SELECT id, body, user_id, created_at, updated_at, rechirp_of, quote_of, edited_at, deleted_at, hidden_at, held_at, content_warning, sensitive, visibility, pinned_at, scheduled_for, expires_at FROM chirps
+WHERE held_at IS NOT NULL
+AND (held_at, id) < ($1::timestamp, $2::uuid)
+AND deleted_at IS NULL
+AND hidden_at IS NULL
+AND (expires_at IS NULL OR expires_at > now())
+ORDER BY held_at DESC, id DESC
+LIMIT $3
+`
+
+type GetHeldChirpsParams struct {
+	Before   time.Time
+	BeforeID uuid.UUID
+	PageSize int32
+}
+
+func (q *Queries) GetHeldChirps(ctx context.Context, arg GetHeldChirpsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getHeldChirps, arg.Before, arg.BeforeID, arg.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.Body,
+			&i.UserID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.RechirpOf,
+			&i.QuoteOf,
+			&i.EditedAt,
+			&i.DeletedAt,
+			&i.HiddenAt,
+			&i.HeldAt,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.Visibility,
+			&i.PinnedAt,
+			&i.ScheduledFor,
+			&i.ExpiresAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPinnedChirps = `-- name: GetPinnedChirps :many
+SELECT chirps.id, chirps.body, chirps.user_id, chirps.created_at, chirps.updated_at, chirps.rechirp_of, chirps.quote_of, chirps.edited_at, chirps.deleted_at, chirps.hidden_at, chirps.held_at, chirps.content_warning, chirps.sensitive, chirps.visibility, chirps.pinned_at, chirps.scheduled_for, chirps.expires_at FROM chirps
+WHERE chirps.user_id = $1
+AND chirps.pinned_at IS NOT NULL
+AND chirp_live(chirps)
+AND chirp_readable(chirps, $2)
+AND NOT ($3::bool AND chirp_sensitive(chirps))
+ORDER BY chirps.pinned_at DESC
+`
+
+type GetPinnedChirpsParams struct {
+	AuthorID      uuid.UUID
+	ViewerID      uuid.UUID
+	HideSensitive bool
+}
+
+func (q *Queries) GetPinnedChirps(ctx context.Context, arg GetPinnedChirpsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getPinnedChirps, arg.AuthorID, arg.ViewerID, arg.HideSensitive)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.Body,
+			&i.UserID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.RechirpOf,
+			&i.QuoteOf,
+			&i.EditedAt,
+			&i.DeletedAt,
+			&i.HiddenAt,
+			&i.HeldAt,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.Visibility,
+			&i.PinnedAt,
+			&i.ScheduledFor,
+			&i.ExpiresAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getScheduledChirp = `-- name: GetScheduledChirp :one
+SELECT id, body, user_id, created_at, updated_at, rechirp_of, quote_of, edited_at, deleted_at, hidden_at, held_at, content_warning, sensitive, visibility, pinned_at, scheduled_for, expires_at FROM chirps
+WHERE id = $1
+AND user_id = $2
+AND scheduled_for IS NOT NULL
+AND deleted_at IS NULL
+FOR UPDATE
+`
+
+type GetScheduledChirpParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) GetScheduledChirp(ctx context.Context, arg GetScheduledChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getScheduledChirp, arg.ID, arg.UserID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.Body,
+		&i.UserID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.RechirpOf,
+		&i.QuoteOf,
+		&i.EditedAt,
+		&i.DeletedAt,
+		&i.HiddenAt,
+		&i.HeldAt,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.Visibility,
+		&i.PinnedAt,
+		&i.ScheduledFor,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const getScheduledChirps = `-- name: GetScheduledChirps :many
+SELECT id, body, user_id, created_at, updated_at, rechirp_of, quote_of, edited_at, deleted_at, hidden_at, held_at, content_warning, sensitive, visibility, pinned_at, scheduled_for, expires_at FROM chirps
+WHERE user_id = $1
+AND scheduled_for IS NOT NULL
+AND deleted_at IS NULL
+ORDER BY scheduled_for ASC
 `
 
-func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getChirps)
+func (q *Queries) GetScheduledChirps(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getScheduledChirps, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -74,6 +678,18 @@ func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
 			&i.UserID,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.RechirpOf,
+			&i.QuoteOf,
+			&i.EditedAt,
+			&i.DeletedAt,
+			&i.HiddenAt,
+			&i.HeldAt,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.Visibility,
+			&i.PinnedAt,
+			&i.ScheduledFor,
+			&i.ExpiresAt,
 		); err != nil {
 			return nil, err
 		}
@@ -87,3 +703,440 @@ func (q *Queries) GetChirps(ctx context.Context) ([]Chirp, error) {
 	}
 	return items, nil
 }
+
+const hideChirp = `-- name: HideChirp :one
+UPDATE chirps
+SET hidden_at = now()
+WHERE id = $1
+RETURNING id, body, user_id, created_at, updated_at, rechirp_of, quote_of, edited_at, deleted_at, hidden_at, held_at, content_warning, sensitive, visibility, pinned_at, scheduled_for, expires_at
+`
+
+func (q *Queries) HideChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, hideChirp, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.Body,
+		&i.UserID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.RechirpOf,
+		&i.QuoteOf,
+		&i.EditedAt,
+		&i.DeletedAt,
+		&i.HiddenAt,
+		&i.HeldAt,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.Visibility,
+		&i.PinnedAt,
+		&i.ScheduledFor,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const pinChirp = `-- name: PinChirp :exec
+UPDATE chirps
+SET pinned_at = now()
+WHERE id = $1
+`
+
+func (q *Queries) PinChirp(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, pinChirp, id)
+	return err
+}
+
+const publishDueChirps = `-- name: PublishDueChirps :many
+UPDATE chirps
+SET created_at = scheduled_for,
+    updated_at = now(),
+    scheduled_for = NULL
+WHERE id IN (
+    SELECT id FROM chirps
+    WHERE scheduled_for <= now()
+    AND held_at IS NULL
+    AND deleted_at IS NULL
+    ORDER BY scheduled_for
+    LIMIT $1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, body, user_id, created_at, updated_at, rechirp_of, quote_of, edited_at, deleted_at, hidden_at, held_at, content_warning, sensitive, visibility, pinned_at, scheduled_for, expires_at
+`
+
+func (q *Queries) PublishDueChirps(ctx context.Context, limit int32) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, publishDueChirps, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.Body,
+			&i.UserID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.RechirpOf,
+			&i.QuoteOf,
+			&i.EditedAt,
+			&i.DeletedAt,
+			&i.HiddenAt,
+			&i.HeldAt,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.Visibility,
+			&i.PinnedAt,
+			&i.ScheduledFor,
+			&i.ExpiresAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const purgeChirpsOfDeletedUsers = `-- name: PurgeChirpsOfDeletedUsers :execrows
+DELETE FROM chirps
+WHERE id IN (
+    SELECT chirps.id FROM chirps
+    INNER JOIN users
+    ON users.id = chirps.user_id
+    WHERE users.deleted_at < $1::timestamp
+    LIMIT $2
+    FOR UPDATE OF chirps SKIP LOCKED
+)
+`
+
+type PurgeChirpsOfDeletedUsersParams struct {
+	Cutoff    time.Time
+	BatchSize int32
+}
+
+func (q *Queries) PurgeChirpsOfDeletedUsers(ctx context.Context, arg PurgeChirpsOfDeletedUsersParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purgeChirpsOfDeletedUsers, arg.Cutoff, arg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const purgeDeletedChirps = `-- name: PurgeDeletedChirps :execrows
+DELETE FROM chirps
+WHERE id IN (
+    SELECT id FROM chirps
+    WHERE deleted_at < $1::timestamp
+    LIMIT $2
+    FOR UPDATE SKIP LOCKED
+)
+`
+
+type PurgeDeletedChirpsParams struct {
+	Cutoff    time.Time
+	BatchSize int32
+}
+
+func (q *Queries) PurgeDeletedChirps(ctx context.Context, arg PurgeDeletedChirpsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purgeDeletedChirps, arg.Cutoff, arg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const rejectHeldChirp = `-- name: RejectHeldChirp :one
+UPDATE chirps
+SET held_at = NULL,
+    hidden_at = now()
+WHERE id = $1
+AND held_at IS NOT NULL
+AND deleted_at IS NULL
+RETURNING id, body, user_id, created_at, updated_at, rechirp_of, quote_of, edited_at, deleted_at, hidden_at, held_at, content_warning, sensitive, visibility, pinned_at, scheduled_for, expires_at
+`
+
+func (q *Queries) RejectHeldChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, rejectHeldChirp, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.Body,
+		&i.UserID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.RechirpOf,
+		&i.QuoteOf,
+		&i.EditedAt,
+		&i.DeletedAt,
+		&i.HiddenAt,
+		&i.HeldAt,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.Visibility,
+		&i.PinnedAt,
+		&i.ScheduledFor,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const releaseHeldChirp = `-- name: ReleaseHeldChirp :one
+UPDATE chirps
+SET held_at = NULL
+WHERE id = $1
+AND held_at IS NOT NULL
+AND deleted_at IS NULL
+RETURNING id, body, user_id, created_at, updated_at, rechirp_of, quote_of, edited_at, deleted_at, hidden_at, held_at, content_warning, sensitive, visibility, pinned_at, scheduled_for, expires_at
+`
+
+func (q *Queries) ReleaseHeldChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, releaseHeldChirp, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.Body,
+		&i.UserID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.RechirpOf,
+		&i.QuoteOf,
+		&i.EditedAt,
+		&i.DeletedAt,
+		&i.HiddenAt,
+		&i.HeldAt,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.Visibility,
+		&i.PinnedAt,
+		&i.ScheduledFor,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const restoreChirp = `-- name: RestoreChirp :one
+UPDATE chirps
+SET deleted_at = NULL
+WHERE id = $1
+AND user_id = $2
+AND deleted_at > $3::timestamp
+AND (expires_at IS NULL OR expires_at > now())
+RETURNING id, body, user_id, created_at, updated_at, rechirp_of, quote_of, edited_at, deleted_at, hidden_at, held_at, content_warning, sensitive, visibility, pinned_at, scheduled_for, expires_at
+`
+
+type RestoreChirpParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+	Cutoff time.Time
+}
+
+func (q *Queries) RestoreChirp(ctx context.Context, arg RestoreChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, restoreChirp, arg.ID, arg.UserID, arg.Cutoff)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.Body,
+		&i.UserID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.RechirpOf,
+		&i.QuoteOf,
+		&i.EditedAt,
+		&i.DeletedAt,
+		&i.HiddenAt,
+		&i.HeldAt,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.Visibility,
+		&i.PinnedAt,
+		&i.ScheduledFor,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const softDeleteChirp = `-- name: SoftDeleteChirp :execrows
+UPDATE chirps
+SET deleted_at = now(),
+    pinned_at = NULL
+WHERE id = $1
+AND user_id = $2
+AND deleted_at IS NULL
+`
+
+type SoftDeleteChirpParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) SoftDeleteChirp(ctx context.Context, arg SoftDeleteChirpParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, softDeleteChirp, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const unpinChirp = `-- name: UnpinChirp :execrows
+UPDATE chirps
+SET pinned_at = NULL
+WHERE id = $1
+AND user_id = $2
+AND pinned_at IS NOT NULL
+`
+
+type UnpinChirpParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) UnpinChirp(ctx context.Context, arg UnpinChirpParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, unpinChirp, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateChirpBody = `-- name: UpdateChirpBody :one
+UPDATE chirps
+SET body = $1,
+    held_at = COALESCE(held_at, $2),
+    updated_at = now(),
+    edited_at = now()
+WHERE id = $3
+RETURNING id, body, user_id, created_at, updated_at, rechirp_of, quote_of, edited_at, deleted_at, hidden_at, held_at, content_warning, sensitive, visibility, pinned_at, scheduled_for, expires_at
+`
+
+type UpdateChirpBodyParams struct {
+	Body   string
+	HeldAt sql.NullTime
+	ID     uuid.UUID
+}
+
+func (q *Queries) UpdateChirpBody(ctx context.Context, arg UpdateChirpBodyParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, updateChirpBody, arg.Body, arg.HeldAt, arg.ID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.Body,
+		&i.UserID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.RechirpOf,
+		&i.QuoteOf,
+		&i.EditedAt,
+		&i.DeletedAt,
+		&i.HiddenAt,
+		&i.HeldAt,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.Visibility,
+		&i.PinnedAt,
+		&i.ScheduledFor,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const updateChirpSensitivity = `-- name: UpdateChirpSensitivity :one
+UPDATE chirps
+SET content_warning = $1,
+    sensitive = $2
+WHERE id = $3
+AND deleted_at IS NULL
+RETURNING id, body, user_id, created_at, updated_at, rechirp_of, quote_of, edited_at, deleted_at, hidden_at, held_at, content_warning, sensitive, visibility, pinned_at, scheduled_for, expires_at
+`
+
+type UpdateChirpSensitivityParams struct {
+	ContentWarning sql.NullString
+	Sensitive      bool
+	ID             uuid.UUID
+}
+
+func (q *Queries) UpdateChirpSensitivity(ctx context.Context, arg UpdateChirpSensitivityParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, updateChirpSensitivity, arg.ContentWarning, arg.Sensitive, arg.ID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.Body,
+		&i.UserID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.RechirpOf,
+		&i.QuoteOf,
+		&i.EditedAt,
+		&i.DeletedAt,
+		&i.HiddenAt,
+		&i.HeldAt,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.Visibility,
+		&i.PinnedAt,
+		&i.ScheduledFor,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const updateScheduledChirp = `-- name: UpdateScheduledChirp :one
+UPDATE chirps
+SET body = $1,
+    scheduled_for = $2,
+    expires_at = $3,
+    held_at = COALESCE(held_at, $4),
+    updated_at = now()
+WHERE id = $5
+AND user_id = $6
+AND scheduled_for IS NOT NULL
+RETURNING id, body, user_id, created_at, updated_at, rechirp_of, quote_of, edited_at, deleted_at, hidden_at, held_at, content_warning, sensitive, visibility, pinned_at, scheduled_for, expires_at
+`
+
+type UpdateScheduledChirpParams struct {
+	Body         string
+	ScheduledFor sql.NullTime
+	ExpiresAt    sql.NullTime
+	HeldAt       sql.NullTime
+	ID           uuid.UUID
+	UserID       uuid.UUID
+}
+
+func (q *Queries) UpdateScheduledChirp(ctx context.Context, arg UpdateScheduledChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, updateScheduledChirp,
+		arg.Body,
+		arg.ScheduledFor,
+		arg.ExpiresAt,
+		arg.HeldAt,
+		arg.ID,
+		arg.UserID,
+	)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.Body,
+		&i.UserID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.RechirpOf,
+		&i.QuoteOf,
+		&i.EditedAt,
+		&i.DeletedAt,
+		&i.HiddenAt,
+		&i.HeldAt,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.Visibility,
+		&i.PinnedAt,
+		&i.ScheduledFor,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
@@ -6,15 +6,181 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+type AutomodResult struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	ChirpID   uuid.NullUUID
+	Body      string
+	Rule      string
+	Verdict   string
+	Reason    string
+	CreatedAt time.Time
+}
+
+type BannedWord struct {
+	Word      string
+	CreatedBy uuid.NullUUID
+	CreatedAt time.Time
+}
+
+type Bookmark struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
 type Chirp struct {
+	ID             uuid.UUID
+	Body           string
+	UserID         uuid.UUID
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	RechirpOf      uuid.NullUUID
+	QuoteOf        uuid.NullUUID
+	EditedAt       sql.NullTime
+	DeletedAt      sql.NullTime
+	HiddenAt       sql.NullTime
+	HeldAt         sql.NullTime
+	ContentWarning sql.NullString
+	Sensitive      bool
+	Visibility     string
+	PinnedAt       sql.NullTime
+	ScheduledFor   sql.NullTime
+	ExpiresAt      sql.NullTime
+}
+
+type ChirpAttachment struct {
+	ChirpID  uuid.UUID
+	MediaID  uuid.UUID
+	Position int32
+	AltText  string
+}
+
+type ChirpHashtag struct {
+	ChirpID   uuid.UUID
+	HashtagID uuid.UUID
+	CreatedAt time.Time
+}
+
+type ChirpMention struct {
+	ChirpID     uuid.UUID
+	UserID      uuid.UUID
+	StartOffset int32
+	EndOffset   int32
+	CreatedAt   time.Time
+}
+
+type ChirpRevision struct {
 	ID        uuid.UUID
+	ChirpID   uuid.UUID
 	Body      string
+	WrittenAt time.Time
+	CreatedAt time.Time
+}
+
+type ChirpSearch struct {
+	ChirpID  uuid.UUID
+	Document interface{}
+}
+
+type Draft struct {
+	ID             uuid.UUID
+	UserID         uuid.UUID
+	Body           string
+	QuoteOf        uuid.NullUUID
+	ContentWarning sql.NullString
+	Sensitive      bool
+	Visibility     string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+type Follow struct {
+	FollowerID uuid.UUID
+	FolloweeID uuid.UUID
+	CreatedAt  time.Time
+}
+
+type Hashtag struct {
+	ID        uuid.UUID
+	Tag       string
+	CreatedAt time.Time
+}
+
+type Link struct {
+	ID        uuid.UUID
+	Code      string
+	Url       string
+	Clicks    int64
+	CreatedAt time.Time
+}
+
+type LinkPreview struct {
+	LinkID      uuid.UUID
+	Title       string
+	Description string
+	ImageUrl    string
+	FetchedAt   time.Time
+}
+
+type MediaFile struct {
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	StorageKey  string
+	ContentType string
+	Width       int32
+	Height      int32
+	Size        int32
+	CreatedAt   time.Time
+}
+
+type ModerationAction struct {
+	ID          uuid.UUID
+	ModeratorID uuid.NullUUID
+	Action      string
+	ReportID    uuid.NullUUID
+	ChirpID     uuid.NullUUID
+	UserID      uuid.NullUUID
+	Note        string
+	CreatedAt   time.Time
+}
+
+type Notification struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Kind      string
+	ActorID   uuid.NullUUID
+	ChirpID   uuid.NullUUID
+	Data      json.RawMessage
+	ReadAt    sql.NullTime
+	CreatedAt time.Time
+}
+
+type Poll struct {
+	ChirpID           uuid.UUID
+	ClosesAt          time.Time
+	AllowVoteChange   bool
+	ResultsVisibility string
+	CreatedAt         time.Time
+}
+
+type PollOption struct {
+	ID       uuid.UUID
+	ChirpID  uuid.UUID
+	Position int32
+	Text     string
+}
+
+type PollVote struct {
+	ChirpID   uuid.UUID
 	UserID    uuid.UUID
+	OptionID  uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
@@ -28,10 +194,40 @@ type RefreshToken struct {
 	RevokedAt sql.NullTime
 }
 
-type User struct {
+type Report struct {
 	ID             uuid.UUID
-	Email          string
+	ReporterID     uuid.UUID
+	ChirpID        uuid.NullUUID
+	ReportedUserID uuid.UUID
+	Reason         string
+	Details        string
+	Status         string
+	AssignedTo     uuid.NullUUID
+	Resolution     sql.NullString
+	ResolvedBy     uuid.NullUUID
+	ResolvedAt     sql.NullTime
 	CreatedAt      time.Time
 	UpdatedAt      time.Time
-	HashedPassword string
+	AboutChirp     bool
+}
+
+type User struct {
+	ID              uuid.UUID
+	Email           string
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	HashedPassword  string
+	Handle          sql.NullString
+	DeletedAt       sql.NullTime
+	Role            string
+	Tier            string
+	SuspendedUntil  sql.NullTime
+	BannedAt        sql.NullTime
+	ExpandSensitive bool
+	Avatar          sql.NullString
+}
+
+type UserSearch struct {
+	UserID   uuid.UUID
+	Document interface{}
 }
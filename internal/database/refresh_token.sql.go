@@ -50,6 +50,9 @@ ON users.id = refresh_tokens.user_id
 WHERE refresh_tokens.token = $1
 AND refresh_tokens.revoked_at IS NULL
 AND refresh_tokens.expires_at >= now()
+AND users.deleted_at IS NULL
+AND users.banned_at IS NULL
+AND (users.suspended_until IS NULL OR users.suspended_until <= now())
 `
 
 type GetUserFromRefreshTokenRow struct {
@@ -97,3 +100,16 @@ func (q *Queries) RevokeRefreshToken(ctx context.Context, arg RevokeRefreshToken
 	)
 	return i, err
 }
+
+const revokeUserRefreshTokens = `-- name: RevokeUserRefreshTokens :exec
+UPDATE refresh_tokens
+SET revoked_at = now(),
+    updated_at = now()
+WHERE user_id = $1
+AND revoked_at IS NULL
+`
+
+func (q *Queries) RevokeUserRefreshTokens(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, revokeUserRefreshTokens, userID)
+	return err
+}
@@ -7,24 +7,47 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const banUser = `-- name: BanUser :execrows
+UPDATE users
+SET banned_at = now(),
+    updated_at = now()
+WHERE id = $1
+AND deleted_at IS NULL
+`
+
+func (q *Queries) BanUser(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, banUser, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const createUser = `-- name: CreateUser :one
-INSERT INTO users (email, hashed_password)
+INSERT INTO users (email, hashed_password, handle)
 VALUES (
     $1,
-    $2
+    $2,
+    $3
 )
-RETURNING id, email, created_at, updated_at, hashed_password
+RETURNING id, email, created_at, updated_at, hashed_password, handle, deleted_at, role, tier, suspended_until, banned_at, expand_sensitive, avatar
 `
 
 type CreateUserParams struct {
 	Email          string
 	HashedPassword string
+	Handle         sql.NullString
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, createUser, arg.Email, arg.HashedPassword)
+	row := q.db.QueryRowContext(ctx, createUser, arg.Email, arg.HashedPassword, arg.Handle)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -32,6 +55,14 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.HashedPassword,
+		&i.Handle,
+		&i.DeletedAt,
+		&i.Role,
+		&i.Tier,
+		&i.SuspendedUntil,
+		&i.BannedAt,
+		&i.ExpandSensitive,
+		&i.Avatar,
 	)
 	return i, err
 }
@@ -45,8 +76,99 @@ func (q *Queries) DeleteUsers(ctx context.Context) error {
 	return err
 }
 
+const getDeletedUserByEmail = `-- name: GetDeletedUserByEmail :one
+SELECT id, email, created_at, updated_at, hashed_password, handle, deleted_at, role, tier, suspended_until, banned_at, expand_sensitive, avatar FROM users
+WHERE email = $1
+AND deleted_at > $2::timestamp
+`
+
+type GetDeletedUserByEmailParams struct {
+	Email  string
+	Cutoff time.Time
+}
+
+func (q *Queries) GetDeletedUserByEmail(ctx context.Context, arg GetDeletedUserByEmailParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, getDeletedUserByEmail, arg.Email, arg.Cutoff)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.HashedPassword,
+		&i.Handle,
+		&i.DeletedAt,
+		&i.Role,
+		&i.Tier,
+		&i.SuspendedUntil,
+		&i.BannedAt,
+		&i.ExpandSensitive,
+		&i.Avatar,
+	)
+	return i, err
+}
+
+const getExpandSensitive = `-- name: GetExpandSensitive :one
+SELECT expand_sensitive FROM users
+WHERE id = $1
+`
+
+func (q *Queries) GetExpandSensitive(ctx context.Context, id uuid.UUID) (bool, error) {
+	row := q.db.QueryRowContext(ctx, getExpandSensitive, id)
+	var expand_sensitive bool
+	err := row.Scan(&expand_sensitive)
+	return expand_sensitive, err
+}
+
+const getUser = `-- name: GetUser :one
+SELECT id, email, created_at, updated_at, hashed_password, handle, deleted_at, role, tier, suspended_until, banned_at, expand_sensitive, avatar FROM users
+WHERE id = $1
+AND deleted_at IS NULL
+`
+
+func (q *Queries) GetUser(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUser, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.HashedPassword,
+		&i.Handle,
+		&i.DeletedAt,
+		&i.Role,
+		&i.Tier,
+		&i.SuspendedUntil,
+		&i.BannedAt,
+		&i.ExpandSensitive,
+		&i.Avatar,
+	)
+	return i, err
+}
+
+const getUserAccess = `-- name: GetUserAccess :one
+SELECT banned_at, suspended_until FROM users
+WHERE id = $1
+AND deleted_at IS NULL
+`
+
+type GetUserAccessRow struct {
+	BannedAt       sql.NullTime
+	SuspendedUntil sql.NullTime
+}
+
+func (q *Queries) GetUserAccess(ctx context.Context, id uuid.UUID) (GetUserAccessRow, error) {
+	row := q.db.QueryRowContext(ctx, getUserAccess, id)
+	var i GetUserAccessRow
+	err := row.Scan(&i.BannedAt, &i.SuspendedUntil)
+	return i, err
+}
+
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, email, created_at, updated_at, hashed_password FROM users WHERE email = $1
+SELECT id, email, created_at, updated_at, hashed_password, handle, deleted_at, role, tier, suspended_until, banned_at, expand_sensitive, avatar FROM users
+WHERE email = $1
+AND deleted_at IS NULL
 `
 
 func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
@@ -58,6 +180,291 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.HashedPassword,
+		&i.Handle,
+		&i.DeletedAt,
+		&i.Role,
+		&i.Tier,
+		&i.SuspendedUntil,
+		&i.BannedAt,
+		&i.ExpandSensitive,
+		&i.Avatar,
+	)
+	return i, err
+}
+
+const getUserRole = `-- name: GetUserRole :one
+SELECT role FROM users
+WHERE id = $1
+AND deleted_at IS NULL
+`
+
+func (q *Queries) GetUserRole(ctx context.Context, id uuid.UUID) (string, error) {
+	row := q.db.QueryRowContext(ctx, getUserRole, id)
+	var role string
+	err := row.Scan(&role)
+	return role, err
+}
+
+const getUserTier = `-- name: GetUserTier :one
+SELECT tier FROM users
+WHERE id = $1
+`
+
+func (q *Queries) GetUserTier(ctx context.Context, id uuid.UUID) (string, error) {
+	row := q.db.QueryRowContext(ctx, getUserTier, id)
+	var tier string
+	err := row.Scan(&tier)
+	return tier, err
+}
+
+const getUsersByHandles = `-- name: GetUsersByHandles :many
+SELECT id, handle FROM users
+WHERE handle = ANY($1::text[])
+AND deleted_at IS NULL
+`
+
+type GetUsersByHandlesRow struct {
+	ID     uuid.UUID
+	Handle sql.NullString
+}
+
+func (q *Queries) GetUsersByHandles(ctx context.Context, handles []string) ([]GetUsersByHandlesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getUsersByHandles, pq.Array(handles))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUsersByHandlesRow
+	for rows.Next() {
+		var i GetUsersByHandlesRow
+		if err := rows.Scan(&i.ID, &i.Handle); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockUser = `-- name: LockUser :exec
+SELECT id FROM users
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) LockUser(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, lockUser, id)
+	return err
+}
+
+const purgeDeletedUsers = `-- name: PurgeDeletedUsers :execrows
+DELETE FROM users
+WHERE id IN (
+    SELECT id FROM users
+    WHERE deleted_at < $1::timestamp
+    AND NOT EXISTS (
+        SELECT 1 FROM chirps
+        WHERE chirps.user_id = users.id
+    )
+    LIMIT $2
+    FOR UPDATE SKIP LOCKED
+)
+`
+
+type PurgeDeletedUsersParams struct {
+	Cutoff    time.Time
+	BatchSize int32
+}
+
+func (q *Queries) PurgeDeletedUsers(ctx context.Context, arg PurgeDeletedUsersParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purgeDeletedUsers, arg.Cutoff, arg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const restoreUser = `-- name: RestoreUser :one
+UPDATE users
+SET deleted_at = NULL,
+    updated_at = now()
+WHERE id = $1
+RETURNING id, email, created_at, updated_at, hashed_password, handle, deleted_at, role, tier, suspended_until, banned_at, expand_sensitive, avatar
+`
+
+func (q *Queries) RestoreUser(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, restoreUser, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.HashedPassword,
+		&i.Handle,
+		&i.DeletedAt,
+		&i.Role,
+		&i.Tier,
+		&i.SuspendedUntil,
+		&i.BannedAt,
+		&i.ExpandSensitive,
+		&i.Avatar,
+	)
+	return i, err
+}
+
+const softDeleteUser = `-- name: SoftDeleteUser :exec
+UPDATE users
+SET deleted_at = now(),
+    updated_at = now()
+WHERE id = $1
+`
+
+func (q *Queries) SoftDeleteUser(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, softDeleteUser, id)
+	return err
+}
+
+const suspendUser = `-- name: SuspendUser :execrows
+UPDATE users
+SET suspended_until = $1,
+    updated_at = now()
+WHERE id = $2
+AND deleted_at IS NULL
+`
+
+type SuspendUserParams struct {
+	SuspendedUntil sql.NullTime
+	ID             uuid.UUID
+}
+
+func (q *Queries) SuspendUser(ctx context.Context, arg SuspendUserParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, suspendUser, arg.SuspendedUntil, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const unbanUser = `-- name: UnbanUser :execrows
+UPDATE users
+SET banned_at = NULL,
+    updated_at = now()
+WHERE id = $1
+AND deleted_at IS NULL
+`
+
+func (q *Queries) UnbanUser(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, unbanUser, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateUserAvatar = `-- name: UpdateUserAvatar :one
+UPDATE users
+SET avatar = $1,
+    updated_at = now()
+WHERE id = $2
+RETURNING id, email, created_at, updated_at, hashed_password, handle, deleted_at, role, tier, suspended_until, banned_at, expand_sensitive, avatar
+`
+
+type UpdateUserAvatarParams struct {
+	Avatar sql.NullString
+	ID     uuid.UUID
+}
+
+func (q *Queries) UpdateUserAvatar(ctx context.Context, arg UpdateUserAvatarParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserAvatar, arg.Avatar, arg.ID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.HashedPassword,
+		&i.Handle,
+		&i.DeletedAt,
+		&i.Role,
+		&i.Tier,
+		&i.SuspendedUntil,
+		&i.BannedAt,
+		&i.ExpandSensitive,
+		&i.Avatar,
+	)
+	return i, err
+}
+
+const updateUserHandle = `-- name: UpdateUserHandle :one
+UPDATE users
+SET handle = $1,
+    updated_at = now()
+WHERE id = $2
+RETURNING id, email, created_at, updated_at, hashed_password, handle, deleted_at, role, tier, suspended_until, banned_at, expand_sensitive, avatar
+`
+
+type UpdateUserHandleParams struct {
+	Handle sql.NullString
+	ID     uuid.UUID
+}
+
+func (q *Queries) UpdateUserHandle(ctx context.Context, arg UpdateUserHandleParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserHandle, arg.Handle, arg.ID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.HashedPassword,
+		&i.Handle,
+		&i.DeletedAt,
+		&i.Role,
+		&i.Tier,
+		&i.SuspendedUntil,
+		&i.BannedAt,
+		&i.ExpandSensitive,
+		&i.Avatar,
+	)
+	return i, err
+}
+
+const updateUserPreferences = `-- name: UpdateUserPreferences :one
+UPDATE users
+SET expand_sensitive = $1,
+    updated_at = now()
+WHERE id = $2
+RETURNING id, email, created_at, updated_at, hashed_password, handle, deleted_at, role, tier, suspended_until, banned_at, expand_sensitive, avatar
+`
+
+type UpdateUserPreferencesParams struct {
+	ExpandSensitive bool
+	ID              uuid.UUID
+}
+
+func (q *Queries) UpdateUserPreferences(ctx context.Context, arg UpdateUserPreferencesParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserPreferences, arg.ExpandSensitive, arg.ID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.HashedPassword,
+		&i.Handle,
+		&i.DeletedAt,
+		&i.Role,
+		&i.Tier,
+		&i.SuspendedUntil,
+		&i.BannedAt,
+		&i.ExpandSensitive,
+		&i.Avatar,
 	)
 	return i, err
 }
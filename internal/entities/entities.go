@@ -0,0 +1,245 @@
+// Package entities extracts structured entities such as hashtags, mentions
+// and links from chirp bodies. All offsets are measured in runes (Unicode
+// code points) so clients can map them onto the body independently of its
+// byte encoding.
+package entities
+
+import (
+	"net/url"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+const maxHashtagLength = 100
+
+type Hashtag struct {
+	Start int
+	End   int
+	Tag   string
+}
+
+// Hashtags returns every hashtag in body in order of appearance. A hashtag is a
+// '#' that does not follow a word character, followed by letters, digits, marks
+// or underscores, at least one of which is a letter. Tags are normalised with
+// NormalizeHashtag.
+func Hashtags(body string) []Hashtag {
+	runes := []rune(body)
+	var tags []Hashtag
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '#' || (i > 0 && (isWordRune(runes[i-1]) || runes[i-1] == '&')) {
+			continue
+		}
+
+		end := i + 1
+		hasLetter := false
+		for end < len(runes) && isWordRune(runes[end]) {
+			if unicode.IsLetter(runes[end]) {
+				hasLetter = true
+			}
+			end++
+		}
+
+		length := end - i - 1
+		if !hasLetter || length > maxHashtagLength {
+			i = end - 1
+			continue
+		}
+
+		tags = append(tags, Hashtag{
+			Start: i,
+			End:   end,
+			Tag:   NormalizeHashtag(string(runes[i+1 : end])),
+		})
+		i = end - 1
+	}
+	return tags
+}
+
+// UniqueTags returns the distinct tags in hashtags, keeping first-seen order.
+func UniqueTags(hashtags []Hashtag) []string {
+	seen := make(map[string]bool, len(hashtags))
+	var tags []string
+	for _, hashtag := range hashtags {
+		if seen[hashtag.Tag] {
+			continue
+		}
+		seen[hashtag.Tag] = true
+		tags = append(tags, hashtag.Tag)
+	}
+	return tags
+}
+
+// NormalizeHashtag case-folds tag, puts it in Unicode NFC form and strips a
+// leading '#', so that #Go, #GO and go all refer to the same hashtag, as do
+// #STRASSE and #straße, and an accented letter matches however it was typed.
+func NormalizeHashtag(tag string) string {
+	// A Caser keeps state between calls, so each call gets its own.
+	return norm.NFC.String(cases.Fold().String(strings.TrimPrefix(tag, "#")))
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.M, r) || r == '_'
+}
+
+const maxHandleLength = 30
+
+type Mention struct {
+	Start  int
+	End    int
+	Handle string
+}
+
+// Mentions returns every @handle mention in body in order of appearance. An
+// '@' that follows a word character, as in an email address, is not a
+// mention. Handles are normalised with NormalizeHandle.
+func Mentions(body string) []Mention {
+	runes := []rune(body)
+	var mentions []Mention
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '@' || (i > 0 && (isWordRune(runes[i-1]) || runes[i-1] == '@')) {
+			continue
+		}
+
+		end := i + 1
+		for end < len(runes) && isHandleRune(runes[end]) {
+			end++
+		}
+
+		length := end - i - 1
+		if length == 0 || length > maxHandleLength || (end < len(runes) && isWordRune(runes[end])) {
+			i = end - 1
+			continue
+		}
+
+		mentions = append(mentions, Mention{
+			Start:  i,
+			End:    end,
+			Handle: NormalizeHandle(string(runes[i+1 : end])),
+		})
+		i = end - 1
+	}
+	return mentions
+}
+
+// UniqueHandles returns the distinct handles in mentions, keeping first-seen
+// order.
+func UniqueHandles(mentions []Mention) []string {
+	seen := make(map[string]bool, len(mentions))
+	var handles []string
+	for _, mention := range mentions {
+		if seen[mention.Handle] {
+			continue
+		}
+		seen[mention.Handle] = true
+		handles = append(handles, mention.Handle)
+	}
+	return handles
+}
+
+// NormalizeHandle lowercases handle and strips a leading '@'.
+func NormalizeHandle(handle string) string {
+	return strings.ToLower(strings.TrimPrefix(handle, "@"))
+}
+
+// ValidHandle reports whether handle can be mentioned: 1 to 30 ASCII letters,
+// digits or underscores.
+func ValidHandle(handle string) bool {
+	if len(handle) == 0 || len(handle) > maxHandleLength {
+		return false
+	}
+	for _, r := range handle {
+		if !isHandleRune(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func isHandleRune(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_'
+}
+
+type URL struct {
+	Start int
+	End   int
+	URL   string
+}
+
+// URLs returns every http or https URL in body in order of appearance. A URL
+// starts with "http://" or "https://", in any case, that does not follow a
+// word character, and runs up to the next whitespace. Trailing punctuation is
+// left out, as is a closing bracket that does not close one opened inside
+// the URL, so "(see https://example.com/a_(b))." yields
+// "https://example.com/a_(b)".
+func URLs(body string) []URL {
+	runes := []rune(body)
+	var urls []URL
+	for i := 0; i < len(runes); i++ {
+		if i > 0 && isWordRune(runes[i-1]) {
+			continue
+		}
+		scheme := schemeLength(runes[i:])
+		if scheme == 0 {
+			continue
+		}
+
+		end := i + scheme
+		for end < len(runes) && !unicode.IsSpace(runes[end]) {
+			end++
+		}
+		end = trimURL(runes[i:end]) + i
+
+		text := string(runes[i:end])
+		if parsed, err := url.Parse(text); err != nil || parsed.Host == "" {
+			i = end - 1
+			continue
+		}
+
+		urls = append(urls, URL{Start: i, End: end, URL: text})
+		i = end - 1
+	}
+	return urls
+}
+
+// schemeLength returns the length of the "http://" or "https://" prefix of
+// runes, or 0 if there is none.
+func schemeLength(runes []rune) int {
+	for _, scheme := range []string{"http://", "https://"} {
+		if len(runes) >= len(scheme) && strings.EqualFold(string(runes[:len(scheme)]), scheme) {
+			return len(scheme)
+		}
+	}
+	return 0
+}
+
+// trimURL returns the length of runes without trailing punctuation,
+// formatting characters and unbalanced closing brackets.
+func trimURL(runes []rune) int {
+	end := len(runes)
+	for end > 0 {
+		switch last := runes[end-1]; last {
+		case '.', ',', ':', ';', '!', '?', '\'', '"', '*', '`':
+			end--
+		case ')', ']':
+			open := map[rune]rune{')': '(', ']': '['}[last]
+			opened, closed := 0, 0
+			for _, r := range runes[:end] {
+				if r == open {
+					opened++
+				} else if r == last {
+					closed++
+				}
+			}
+			if closed <= opened {
+				return end
+			}
+			end--
+		default:
+			return end
+		}
+	}
+	return end
+}
@@ -0,0 +1,228 @@
+package entities
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHashtags(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []Hashtag
+	}{
+		{
+			name: "Single hashtag",
+			body: "hello #world",
+			want: []Hashtag{{Start: 6, End: 12, Tag: "world"}},
+		},
+		{
+			name: "Mixed case is normalised",
+			body: "#GoLang rocks",
+			want: []Hashtag{{Start: 0, End: 7, Tag: "golang"}},
+		},
+		{
+			name: "Unicode letters and offsets in runes",
+			body: "café #Ünïcödé!",
+			want: []Hashtag{{Start: 5, End: 13, Tag: "ünïcödé"}},
+		},
+		{
+			name: "Case is folded, not just lowered",
+			body: "#STRASSE #straße",
+			want: []Hashtag{
+				{Start: 0, End: 8, Tag: "strasse"},
+				{Start: 9, End: 16, Tag: "strasse"},
+			},
+		},
+		{
+			name: "Decomposed accents are composed",
+			body: "#cafe\u0301",
+			want: []Hashtag{{Start: 0, End: 6, Tag: "café"}},
+		},
+		{
+			name: "Non-latin script",
+			body: "#日本語 と #русский",
+			want: []Hashtag{
+				{Start: 0, End: 4, Tag: "日本語"},
+				{Start: 7, End: 15, Tag: "русский"},
+			},
+		},
+		{
+			name: "Purely numeric tags are ignored",
+			body: "issue #123",
+			want: nil,
+		},
+		{
+			name: "Hash inside a word is ignored",
+			body: "C#sharp and &#39;",
+			want: nil,
+		},
+		{
+			name: "Lone hash",
+			body: "# nothing",
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Hashtags(tt.body)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Hashtags() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUniqueTags(t *testing.T) {
+	got := UniqueTags(Hashtags("#Go #go #rust #GO"))
+	want := []string{"go", "rust"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("UniqueTags() = %v, want %v", got, want)
+	}
+}
+
+func TestMentions(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []Mention
+	}{
+		{
+			name: "Single mention",
+			body: "hi @Alice!",
+			want: []Mention{{Start: 3, End: 9, Handle: "alice"}},
+		},
+		{
+			name: "Offsets in runes",
+			body: "héllo @bob_99 and @carol",
+			want: []Mention{
+				{Start: 6, End: 13, Handle: "bob_99"},
+				{Start: 18, End: 24, Handle: "carol"},
+			},
+		},
+		{
+			name: "Email addresses are not mentions",
+			body: "mail me at dave@example.com",
+			want: nil,
+		},
+		{
+			name: "Non-ASCII handle characters end the mention",
+			body: "@jöhn",
+			want: nil,
+		},
+		{
+			name: "Lone at sign",
+			body: "meet @ noon",
+			want: nil,
+		},
+		{
+			name: "Too long",
+			body: "@" + strings.Repeat("a", 31),
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Mentions(tt.body)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Mentions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidHandle(t *testing.T) {
+	tests := []struct {
+		handle string
+		want   bool
+	}{
+		{handle: "alice", want: true},
+		{handle: "Bob_99", want: true},
+		{handle: "", want: false},
+		{handle: "has space", want: false},
+		{handle: "jöhn", want: false},
+		{handle: strings.Repeat("a", 31), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.handle, func(t *testing.T) {
+			if got := ValidHandle(tt.handle); got != tt.want {
+				t.Errorf("ValidHandle(%q) = %v, want %v", tt.handle, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestURLs(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []URL
+	}{
+		{
+			name: "Single URL",
+			body: "see https://example.com/a?b=c",
+			want: []URL{{Start: 4, End: 29, URL: "https://example.com/a?b=c"}},
+		},
+		{
+			name: "Scheme in any case",
+			body: "HTTP://Example.com",
+			want: []URL{{Start: 0, End: 18, URL: "HTTP://Example.com"}},
+		},
+		{
+			name: "Trailing punctuation is left out",
+			body: "read http://example.com/post.",
+			want: []URL{{Start: 5, End: 28, URL: "http://example.com/post"}},
+		},
+		{
+			name: "Surrounding formatting is left out",
+			body: "*https://example.com* `http://example.com`",
+			want: []URL{{Start: 1, End: 20, URL: "https://example.com"}, {Start: 23, End: 41, URL: "http://example.com"}},
+		},
+		{
+			name: "Surrounding brackets are left out",
+			body: "(https://example.com/a_(b))",
+			want: []URL{{Start: 1, End: 26, URL: "https://example.com/a_(b)"}},
+		},
+		{
+			name: "Offsets in runes",
+			body: "café → https://example.com",
+			want: []URL{{Start: 7, End: 26, URL: "https://example.com"}},
+		},
+		{
+			name: "Several URLs",
+			body: "http://a.example and https://b.example/x",
+			want: []URL{
+				{Start: 0, End: 16, URL: "http://a.example"},
+				{Start: 21, End: 40, URL: "https://b.example/x"},
+			},
+		},
+		{
+			name: "Other schemes are ignored",
+			body: "javascript:alert(1) ftp://example.com mailto:a@example.com",
+			want: nil,
+		},
+		{
+			name: "Scheme inside a word is ignored",
+			body: "xhttp://example.com",
+			want: nil,
+		},
+		{
+			name: "Scheme without a host",
+			body: "https:// nothing",
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := URLs(tt.body)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("URLs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+// Package media validates uploaded images and stores them.
+package media
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+)
+
+var (
+	ErrTooLarge        = errors.New("file is too large")
+	ErrUnsupportedType = errors.New("file type is not supported")
+	ErrInvalid         = errors.New("file is not a valid image")
+	ErrDimensions      = errors.New("image dimensions are too large")
+)
+
+// Limits bounds the uploads Process accepts.
+type Limits struct {
+	MaxBytes  int64
+	MaxWidth  int
+	MaxHeight int
+}
+
+// Image is an uploaded image after re-encoding.
+type Image struct {
+	Data        []byte
+	ContentType string
+	Width       int
+	Height      int
+}
+
+// Ext returns the file extension for the image's content type.
+func (img Image) Ext() string {
+	return extensions[img.ContentType]
+}
+
+var extensions = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+}
+
+// Process reads an uploaded image from r and re-encodes it in its own
+// format, which drops metadata such as EXIF, including GPS positions, along
+// with anything appended to the file. The EXIF orientation is applied to
+// the pixels first, so photos stay upright. Animated GIFs keep only their
+// first frame.
+func Process(r io.Reader, limits Limits) (Image, error) {
+	img, contentType, err := Decode(r, limits)
+	if err != nil {
+		return Image{}, err
+	}
+	return Encode(img, contentType)
+}
+
+// Decode reads an uploaded image from r and returns it upright, with its
+// content type. The type is sniffed from the content rather than trusted
+// from the client, and the dimensions are checked before any pixels are
+// decoded.
+func Decode(r io.Reader, limits Limits) (image.Image, string, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limits.MaxBytes+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if int64(len(data)) > limits.MaxBytes {
+		return nil, "", ErrTooLarge
+	}
+
+	contentType := http.DetectContentType(data)
+	if _, ok := extensions[contentType]; !ok {
+		return nil, "", ErrUnsupportedType
+	}
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || "image/"+format != contentType {
+		return nil, "", ErrInvalid
+	}
+	orientation := 1
+	if contentType == "image/jpeg" {
+		orientation = jpegOrientation(data)
+	}
+	width, height := config.Width, config.Height
+	if swapsAxes(orientation) {
+		width, height = height, width
+	}
+	if width > limits.MaxWidth || height > limits.MaxHeight {
+		return nil, "", ErrDimensions
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", ErrInvalid
+	}
+	return orient(img, orientation), contentType, nil
+}
+
+// Encode encodes img as contentType, one of PNG, JPEG or GIF.
+func Encode(img image.Image, contentType string) (Image, error) {
+	var buf bytes.Buffer
+	var err error
+	switch contentType {
+	case "image/png":
+		err = png.Encode(&buf, img)
+	case "image/jpeg":
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90})
+	case "image/gif":
+		err = gif.Encode(&buf, img, nil)
+	default:
+		return Image{}, ErrUnsupportedType
+	}
+	if err != nil {
+		return Image{}, err
+	}
+
+	bounds := img.Bounds()
+	return Image{
+		Data:        buf.Bytes(),
+		ContentType: contentType,
+		Width:       bounds.Dx(),
+		Height:      bounds.Dy(),
+	}, nil
+}
+
+// Key returns a content-addressed storage key for img, so identical uploads
+// share one file and a stored file never changes.
+func Key(img Image) string {
+	sum := sha256.Sum256(img.Data)
+	return hex.EncodeToString(sum[:]) + img.Ext()
+}
@@ -0,0 +1,269 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func testImage(width, height int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	return img
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// jpegWithEXIF encodes img as a JPEG and inserts an APP1 EXIF segment
+// carrying marker right after the start-of-image marker.
+func jpegWithEXIF(t *testing.T, img image.Image, marker string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	payload := append([]byte("Exif\x00\x00"), marker...)
+	length := len(payload) + 2
+	segment := append([]byte{0xFF, 0xE1, byte(length >> 8), byte(length)}, payload...)
+
+	out := append([]byte{}, data[:2]...)
+	out = append(out, segment...)
+	return append(out, data[2:]...)
+}
+
+// exifOrientation is a big-endian TIFF structure with a single IFD entry
+// holding orientation, for use as the payload of jpegWithEXIF.
+func exifOrientation(orientation int) string {
+	return "MM\x00\x2a\x00\x00\x00\x08" +
+		"\x00\x01" +
+		"\x01\x12\x00\x03\x00\x00\x00\x01\x00" + string(rune(orientation)) + "\x00\x00" +
+		"\x00\x00\x00\x00"
+}
+
+func encodeGIF(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := gif.Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestProcess(t *testing.T) {
+	limits := Limits{MaxBytes: 1 << 20, MaxWidth: 64, MaxHeight: 64}
+	validPNG := encodePNG(t, testImage(32, 16))
+
+	tests := []struct {
+		name           string
+		data           []byte
+		wantType       string
+		wantWidth      int
+		wantHeight     int
+		wantErr        error
+		mustNotContain string
+	}{
+		{
+			name:       "PNG",
+			data:       validPNG,
+			wantType:   "image/png",
+			wantWidth:  32,
+			wantHeight: 16,
+		},
+		{
+			name:           "JPEG loses its EXIF data",
+			data:           jpegWithEXIF(t, testImage(20, 20), "GPS 51.5,-0.1"),
+			wantType:       "image/jpeg",
+			wantWidth:      20,
+			wantHeight:     20,
+			mustNotContain: "GPS 51.5,-0.1",
+		},
+		{
+			name:       "GIF",
+			data:       encodeGIF(t, testImage(8, 8)),
+			wantType:   "image/gif",
+			wantWidth:  8,
+			wantHeight: 8,
+		},
+		{
+			name:           "Data appended after the image is dropped",
+			data:           append(append([]byte{}, validPNG...), "<script>alert(1)</script>"...),
+			wantType:       "image/png",
+			wantWidth:      32,
+			wantHeight:     16,
+			mustNotContain: "<script>",
+		},
+		{
+			name:    "Text is not an image",
+			data:    []byte("hello, world"),
+			wantErr: ErrUnsupportedType,
+		},
+		{
+			name:    "HTML is not an image",
+			data:    []byte("<html><body>hi</body></html>"),
+			wantErr: ErrUnsupportedType,
+		},
+		{
+			name:    "Truncated PNG",
+			data:    validPNG[:20],
+			wantErr: ErrInvalid,
+		},
+		{
+			name:    "Too wide",
+			data:    encodePNG(t, testImage(65, 1)),
+			wantErr: ErrDimensions,
+		},
+		{
+			name:    "Too large",
+			data:    bytes.Repeat([]byte{0}, 1<<20+1),
+			wantErr: ErrTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Process(bytes.NewReader(tt.data), limits)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Process() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if got.ContentType != tt.wantType {
+				t.Errorf("Process() content type = %s, want %s", got.ContentType, tt.wantType)
+			}
+			if got.Width != tt.wantWidth || got.Height != tt.wantHeight {
+				t.Errorf("Process() size = %dx%d, want %dx%d", got.Width, got.Height, tt.wantWidth, tt.wantHeight)
+			}
+			if tt.mustNotContain != "" && bytes.Contains(got.Data, []byte(tt.mustNotContain)) {
+				t.Errorf("Process() output still contains %q", tt.mustNotContain)
+			}
+			if _, _, err := image.Decode(bytes.NewReader(got.Data)); err != nil {
+				t.Errorf("Process() output does not decode: %v", err)
+			}
+		})
+	}
+}
+
+func TestProcessAppliesOrientation(t *testing.T) {
+	// A landscape image, red on the left and blue on the right, as a phone
+	// stores a portrait photo: turned a quarter anticlockwise, with
+	// orientation 6 saying to turn it back.
+	src := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	for x := 0; x < 40; x++ {
+		for y := 0; y < 20; y++ {
+			c := color.RGBA{R: 255, A: 255}
+			if x >= 20 {
+				c = color.RGBA{B: 255, A: 255}
+			}
+			src.Set(x, y, c)
+		}
+	}
+	data := jpegWithEXIF(t, src, exifOrientation(6))
+
+	got, err := Process(bytes.NewReader(data), Limits{MaxBytes: 1 << 20, MaxWidth: 20, MaxHeight: 40})
+	if err != nil {
+		t.Fatalf("Process() error = %v", err)
+	}
+	if got.Width != 20 || got.Height != 40 {
+		t.Fatalf("Process() size = %dx%d, want 20x40", got.Width, got.Height)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(got.Data))
+	if err != nil {
+		t.Fatalf("Process() output does not decode: %v", err)
+	}
+	// A quarter turn clockwise brings the left half to the top.
+	if r, _, b, _ := img.At(10, 5).RGBA(); r < 0xC000 || b > 0x4000 {
+		t.Errorf("top of the image is not red")
+	}
+	if r, _, b, _ := img.At(10, 35).RGBA(); b < 0xC000 || r > 0x4000 {
+		t.Errorf("bottom of the image is not blue")
+	}
+}
+
+func TestOrient(t *testing.T) {
+	// Where the top-left pixel of a 3x2 image ends up, and the size after.
+	tests := []struct {
+		orientation   int
+		wantX, wantY  int
+		width, height int
+	}{
+		{orientation: 1, wantX: 0, wantY: 0, width: 3, height: 2},
+		{orientation: 2, wantX: 2, wantY: 0, width: 3, height: 2},
+		{orientation: 3, wantX: 2, wantY: 1, width: 3, height: 2},
+		{orientation: 4, wantX: 0, wantY: 1, width: 3, height: 2},
+		{orientation: 5, wantX: 0, wantY: 0, width: 2, height: 3},
+		{orientation: 6, wantX: 1, wantY: 0, width: 2, height: 3},
+		{orientation: 7, wantX: 1, wantY: 2, width: 2, height: 3},
+		{orientation: 8, wantX: 0, wantY: 2, width: 2, height: 3},
+	}
+
+	marked := color.RGBA{R: 255, A: 255}
+	for _, tt := range tests {
+		src := image.NewRGBA(image.Rect(0, 0, 3, 2))
+		src.Set(0, 0, marked)
+
+		got := orient(src, tt.orientation)
+		if got.Bounds().Dx() != tt.width || got.Bounds().Dy() != tt.height {
+			t.Errorf("orient(%d) size = %dx%d, want %dx%d", tt.orientation, got.Bounds().Dx(), got.Bounds().Dy(), tt.width, tt.height)
+			continue
+		}
+		if got.At(tt.wantX, tt.wantY) != marked {
+			t.Errorf("orient(%d) did not move the top-left pixel to (%d, %d)", tt.orientation, tt.wantX, tt.wantY)
+		}
+	}
+}
+
+func TestJPEGOrientation(t *testing.T) {
+	img := testImage(4, 4)
+	for _, tt := range []struct {
+		name string
+		data []byte
+		want int
+	}{
+		{name: "Orientation 6", data: jpegWithEXIF(t, img, exifOrientation(6)), want: 6},
+		{name: "Orientation 3", data: jpegWithEXIF(t, img, exifOrientation(3)), want: 3},
+		{name: "EXIF without orientation", data: jpegWithEXIF(t, img, "GPS 51.5,-0.1"), want: 1},
+		{name: "Out of range", data: jpegWithEXIF(t, img, exifOrientation(9)), want: 1},
+		{name: "Truncated", data: jpegWithEXIF(t, img, exifOrientation(6))[:30], want: 1},
+	} {
+		if got := jpegOrientation(tt.data); got != tt.want {
+			t.Errorf("%s: jpegOrientation() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestKey(t *testing.T) {
+	img, err := Encode(testImage(4, 4), "image/png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := Key(img)
+	if key != Key(img) {
+		t.Errorf("Key() is not deterministic")
+	}
+	if !keyPattern.MatchString(key) {
+		t.Errorf("Key() = %q, not a valid storage key", key)
+	}
+	if len(key) != 64+len(".png") {
+		t.Errorf("Key() = %q, want a SHA-256 hex digest with extension", key)
+	}
+}
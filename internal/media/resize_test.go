@@ -0,0 +1,107 @@
+package media
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestCropSquare(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	blue := color.RGBA{B: 255, A: 255}
+
+	tests := []struct {
+		name     string
+		width    int
+		height   int
+		wantSide int
+	}{
+		{name: "Wide", width: 30, height: 10, wantSide: 10},
+		{name: "Tall", width: 10, height: 30, wantSide: 10},
+		{name: "Square", width: 10, height: 10, wantSide: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// A blue square in the middle of a red image.
+			img := image.NewRGBA(image.Rect(0, 0, tt.width, tt.height))
+			for x := 0; x < tt.width; x++ {
+				for y := 0; y < tt.height; y++ {
+					img.Set(x, y, red)
+				}
+			}
+			left, top := (tt.width-tt.wantSide)/2, (tt.height-tt.wantSide)/2
+			for x := left; x < left+tt.wantSide; x++ {
+				for y := top; y < top+tt.wantSide; y++ {
+					img.Set(x, y, blue)
+				}
+			}
+
+			got := CropSquare(img)
+			bounds := got.Bounds()
+			if bounds.Dx() != tt.wantSide || bounds.Dy() != tt.wantSide {
+				t.Fatalf("CropSquare() size = %dx%d, want %dx%d", bounds.Dx(), bounds.Dy(), tt.wantSide, tt.wantSide)
+			}
+			for _, p := range []image.Point{{0, 0}, {tt.wantSide - 1, tt.wantSide - 1}} {
+				if c := got.RGBAAt(p.X, p.Y); c != blue {
+					t.Errorf("CropSquare() pixel %v = %v, want %v", p, c, blue)
+				}
+			}
+		})
+	}
+}
+
+func TestCropSquareOffsetBounds(t *testing.T) {
+	img := image.NewRGBA(image.Rect(100, 100, 120, 110))
+	img.Set(110, 105, color.RGBA{G: 255, A: 255})
+
+	got := CropSquare(img)
+	if c := got.RGBAAt(5, 5); c.G != 255 {
+		t.Errorf("CropSquare() centre pixel = %v, want green", c)
+	}
+}
+
+func TestResize(t *testing.T) {
+	// A 2x2 black and white checkerboard.
+	checker := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	checker.Set(0, 0, color.White)
+	checker.Set(1, 1, color.White)
+	checker.Set(1, 0, color.Black)
+	checker.Set(0, 1, color.Black)
+
+	t.Run("Shrinking averages", func(t *testing.T) {
+		got := Resize(checker, 1, 1)
+		c := got.RGBAAt(0, 0)
+		if c.R != 127 || c.G != 127 || c.B != 127 || c.A != 255 {
+			t.Errorf("Resize() = %v, want mid grey", c)
+		}
+	})
+
+	t.Run("Enlarging repeats pixels", func(t *testing.T) {
+		got := Resize(checker, 4, 4)
+		if got.Bounds().Dx() != 4 || got.Bounds().Dy() != 4 {
+			t.Fatalf("Resize() size = %v, want 4x4", got.Bounds())
+		}
+		if c := got.RGBAAt(1, 1); c.R != 255 {
+			t.Errorf("Resize() top left = %v, want white", c)
+		}
+		if c := got.RGBAAt(2, 1); c.R != 0 {
+			t.Errorf("Resize() top right = %v, want black", c)
+		}
+	})
+
+	t.Run("Uneven ratios cover every pixel", func(t *testing.T) {
+		src := image.NewRGBA(image.Rect(0, 0, 400, 400))
+		for i := range src.Pix {
+			src.Pix[i] = 200
+		}
+		for _, size := range []int{48, 128, 400} {
+			got := Resize(src, size, size)
+			for i, v := range got.Pix {
+				if v != 200 {
+					t.Fatalf("Resize() to %d: byte %d = %d, want 200", size, i, v)
+				}
+			}
+		}
+	})
+}
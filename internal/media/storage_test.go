@@ -0,0 +1,61 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLocal(t *testing.T) {
+	ctx := context.Background()
+	storage, err := NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := storage.Put(ctx, "abc.png", strings.NewReader("image data")); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	file, err := storage.Open(ctx, "abc.png")
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	data, err := io.ReadAll(file)
+	file.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "image data" {
+		t.Errorf("Open() read %q, want %q", data, "image data")
+	}
+
+	if err := storage.Delete(ctx, "abc.png"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := storage.Open(ctx, "abc.png"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Open() after Delete() error = %v, want ErrNotFound", err)
+	}
+	if err := storage.Delete(ctx, "abc.png"); err != nil {
+		t.Errorf("Delete() of a missing file error = %v, want nil", err)
+	}
+}
+
+func TestLocalRejectsBadKeys(t *testing.T) {
+	ctx := context.Background()
+	storage, err := NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{"", "../escape.png", "a/b.png", ".hidden", "UPPER.png"} {
+		if err := storage.Put(ctx, key, strings.NewReader("x")); !errors.Is(err, ErrBadKey) {
+			t.Errorf("Put(%q) error = %v, want ErrBadKey", key, err)
+		}
+		if _, err := storage.Open(ctx, key); !errors.Is(err, ErrBadKey) {
+			t.Errorf("Open(%q) error = %v, want ErrBadKey", key, err)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+// Package moderation censors disallowed words in chirp bodies and screens
+// new chirps with a pipeline of automated rules.
+package moderation
+
+import (
+	"bufio"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Replacement is substituted for every censored word.
+const Replacement = "****"
+
+// leet maps characters commonly substituted for letters back to the letter
+// they stand in for.
+var leet = map[rune]rune{
+	'0': 'o',
+	'1': 'i',
+	'3': 'e',
+	'4': 'a',
+	'5': 's',
+	'7': 't',
+	'8': 'b',
+	'9': 'g',
+	'@': 'a',
+	'$': 's',
+	'!': 'i',
+	'|': 'l',
+	'+': 't',
+}
+
+var folder = cases.Fold()
+
+// Filter censors whole words that match its word list. Matching ignores case,
+// diacritics and common leetspeak substitutions, so "Kérfüff1e" matches
+// "kerfuffle" but "kerfuffles" does not. A Filter is safe for concurrent use
+// and its word list can be replaced at runtime.
+type Filter struct {
+	mu    sync.RWMutex
+	words map[string]bool
+}
+
+func NewFilter(words []string) *Filter {
+	f := &Filter{}
+	f.SetWords(words)
+	return f
+}
+
+// SetWords replaces the filter's word list.
+func (f *Filter) SetWords(words []string) {
+	set := make(map[string]bool, len(words))
+	for _, word := range words {
+		if normalized := Normalize(word); normalized != "" {
+			set[normalized] = true
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.words = set
+}
+
+// Words returns the normalised word list in sorted order.
+func (f *Filter) Words() []string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	words := make([]string, 0, len(f.words))
+	for word := range f.words {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+	return words
+}
+
+// Censor replaces every listed word in text with Replacement. Text is scanned
+// once, so a replacement can never itself be censored again.
+func (f *Filter) Censor(text string) string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if len(f.words) == 0 {
+		return text
+	}
+
+	var out strings.Builder
+	rs := []rune(text)
+	for i := 0; i < len(rs); {
+		if !isTokenRune(rs[i]) {
+			out.WriteRune(rs[i])
+			i++
+			continue
+		}
+
+		end := i
+		for end < len(rs) && isTokenRune(rs[end]) {
+			end++
+		}
+		out.WriteString(f.censorToken(rs[i:end]))
+		i = end
+	}
+	return out.String()
+}
+
+// censorToken checks a run of word and leetspeak characters. Leading and
+// trailing symbols are tried both as punctuation and as letters, so that
+// "fornax!" keeps its exclamation mark while "@ss" is still read as "ass".
+func (f *Filter) censorToken(token []rune) string {
+	start, end := 0, len(token)
+	for start < end && !isWordRune(token[start]) {
+		start++
+	}
+	for end > start && !isWordRune(token[end-1]) {
+		end--
+	}
+
+	if start < end && f.words[Normalize(string(token[start:end]))] {
+		return string(token[:start]) + Replacement + string(token[end:])
+	}
+	if f.words[Normalize(string(token))] {
+		return Replacement
+	}
+	return string(token)
+}
+
+// Normalize folds case, strips diacritics and undoes leetspeak so that
+// variants of a word compare equal.
+func Normalize(word string) string {
+	stripped, _, err := transform.String(transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC), word)
+	if err != nil {
+		stripped = word
+	}
+
+	folded := []rune(folder.String(strings.TrimSpace(stripped)))
+	for i, r := range folded {
+		if letter, ok := leet[r]; ok {
+			folded[i] = letter
+		}
+	}
+	return string(folded)
+}
+
+// LoadWords reads a word list with one word per line. Blank lines and lines
+// starting with '#' are ignored.
+func LoadWords(r io.Reader) ([]string, error) {
+	var words []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, line)
+	}
+	return words, scanner.Err()
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.M, r)
+}
+
+func isTokenRune(r rune) bool {
+	_, ok := leet[r]
+	return isWordRune(r) || ok
+}
@@ -0,0 +1,98 @@
+package moderation
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCensor(t *testing.T) {
+	filter := NewFilter([]string{"kerfuffle", "sharbert", "fornax"})
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{
+			name: "No forbidden words",
+			text: "This is a clean chirp",
+			want: "This is a clean chirp",
+		},
+		{
+			name: "Forbidden word keeps surrounding text",
+			text: "What a kerfuffle this is",
+			want: "What a **** this is",
+		},
+		{
+			name: "Case is ignored",
+			text: "SHARBERT and Fornax",
+			want: "**** and ****",
+		},
+		{
+			name: "Punctuation is kept",
+			text: "fornax! (sharbert)",
+			want: "****! (****)",
+		},
+		{
+			name: "Substrings of other words are not censored",
+			text: "kerfuffles and fornaxes",
+			want: "kerfuffles and fornaxes",
+		},
+		{
+			name: "Diacritics are ignored",
+			text: "a kérfüffle",
+			want: "a ****",
+		},
+		{
+			name: "Leetspeak is undone",
+			text: "sh4rb3rt and f0rn@x",
+			want: "**** and ****",
+		},
+		{
+			name: "Repeated words",
+			text: "fornax fornax fornax",
+			want: "**** **** ****",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := filter.Censor(tt.text); got != tt.want {
+				t.Errorf("Censor() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCensorWordsThatCannotLoop(t *testing.T) {
+	// Uppercase list entries and a list containing the replacement itself
+	// used to loop forever.
+	filter := NewFilter([]string{"FORNAX", Replacement, "****x"})
+
+	got := filter.Censor("fornax ****")
+	if got != "**** ****" {
+		t.Errorf("Censor() = %q, want %q", got, "**** ****")
+	}
+}
+
+func TestSetWords(t *testing.T) {
+	filter := NewFilter([]string{"fornax"})
+	filter.SetWords([]string{"Sharbert"})
+
+	if got := filter.Censor("fornax sharbert"); got != "fornax ****" {
+		t.Errorf("Censor() = %q, want %q", got, "fornax ****")
+	}
+	if got := filter.Words(); len(got) != 1 || got[0] != "sharbert" {
+		t.Errorf("Words() = %v, want [sharbert]", got)
+	}
+}
+
+func TestLoadWords(t *testing.T) {
+	words, err := LoadWords(strings.NewReader("# comment\nfornax\n\n  sharbert  \n"))
+	if err != nil {
+		t.Fatalf("LoadWords() error = %v", err)
+	}
+	if len(words) != 2 || words[0] != "fornax" || words[1] != "sharbert" {
+		t.Errorf("LoadWords() = %v, want [fornax sharbert]", words)
+	}
+}
@@ -0,0 +1,191 @@
+package moderation
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Verdict is a rule's decision about a post. Verdicts are ordered by
+// severity, so the verdict of a pipeline is the most severe of its rules.
+type Verdict int
+
+const (
+	Allow Verdict = iota
+	Flag
+	Reject
+)
+
+func (v Verdict) String() string {
+	switch v {
+	case Allow:
+		return "allow"
+	case Flag:
+		return "flag"
+	case Reject:
+		return "reject"
+	}
+	return fmt.Sprintf("Verdict(%d)", int(v))
+}
+
+// Post is what rules inspect: a chirp about to be written and the context
+// needed to judge it.
+type Post struct {
+	Body string
+	// AccountAge is how long ago the author signed up.
+	AccountAge time.Duration
+	// RecentPosts is how many chirps the author wrote in the posting-rate
+	// window, not counting this one.
+	RecentPosts int
+}
+
+// Result records why a rule did not allow a post.
+type Result struct {
+	Rule    string
+	Verdict Verdict
+	Reason  string
+}
+
+// Rule is one check in a Pipeline. Check returns Allow, or another verdict
+// with a reason a moderator can read.
+type Rule interface {
+	Name() string
+	Check(p Post) (Verdict, string)
+}
+
+// Pipeline runs a fixed list of rules over every post.
+type Pipeline struct {
+	Rules []Rule
+}
+
+// Run checks p against every rule and returns the most severe verdict along
+// with a result for each rule that did not allow it.
+func (pl Pipeline) Run(p Post) (Verdict, []Result) {
+	verdict := Allow
+	var results []Result
+	for _, rule := range pl.Rules {
+		v, reason := rule.Check(p)
+		if v == Allow {
+			continue
+		}
+		results = append(results, Result{Rule: rule.Name(), Verdict: v, Reason: reason})
+		if v > verdict {
+			verdict = v
+		}
+	}
+	return verdict, results
+}
+
+var linkPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)\S+`)
+
+func countLinks(body string) int {
+	return len(linkPattern.FindAllStringIndex(body, -1))
+}
+
+// LinkLimit rejects posts with more than Max links.
+type LinkLimit struct {
+	Max int
+}
+
+func (LinkLimit) Name() string { return "link_limit" }
+
+func (r LinkLimit) Check(p Post) (Verdict, string) {
+	if n := countLinks(p.Body); n > r.Max {
+		return Reject, fmt.Sprintf("contains %d links, at most %d are allowed", n, r.Max)
+	}
+	return Allow, ""
+}
+
+// RepeatedCharacters flags posts that repeat a single character more than
+// Max times in a row, like "soooooooooooo" or "!!!!!!!!!!!!".
+type RepeatedCharacters struct {
+	Max int
+}
+
+func (RepeatedCharacters) Name() string { return "repeated_characters" }
+
+func (r RepeatedCharacters) Check(p Post) (Verdict, string) {
+	var last rune
+	run := 0
+	for _, c := range p.Body {
+		if c == last {
+			run++
+		} else {
+			last, run = c, 1
+		}
+		if run > r.Max && !unicode.IsSpace(c) {
+			return Flag, fmt.Sprintf("repeats %q more than %d times", c, r.Max)
+		}
+	}
+	return Allow, ""
+}
+
+// PostingRate rejects posts from authors who already wrote Max chirps in
+// the posting-rate window.
+type PostingRate struct {
+	Max int
+}
+
+func (PostingRate) Name() string { return "posting_rate" }
+
+func (r PostingRate) Check(p Post) (Verdict, string) {
+	if p.RecentPosts >= r.Max {
+		return Reject, fmt.Sprintf("posted %d chirps in a short time", p.RecentPosts)
+	}
+	return Allow, ""
+}
+
+// NewAccount flags posts containing links from accounts younger than MinAge,
+// a common pattern for spam accounts.
+type NewAccount struct {
+	MinAge time.Duration
+}
+
+func (NewAccount) Name() string { return "new_account" }
+
+func (r NewAccount) Check(p Post) (Verdict, string) {
+	if p.AccountAge < r.MinAge && countLinks(p.Body) > 0 {
+		return Flag, fmt.Sprintf("account is younger than %s and posted a link", r.MinAge)
+	}
+	return Allow, ""
+}
+
+// DenyPatterns rejects posts matching any of its regular expressions.
+type DenyPatterns struct {
+	Patterns []*regexp.Regexp
+}
+
+func (DenyPatterns) Name() string { return "deny_pattern" }
+
+func (r DenyPatterns) Check(p Post) (Verdict, string) {
+	for _, pattern := range r.Patterns {
+		if pattern.MatchString(p.Body) {
+			return Reject, fmt.Sprintf("matches deny-list pattern %q", pattern.String())
+		}
+	}
+	return Allow, ""
+}
+
+// LoadPatterns reads a deny-list with one regular expression per line. Blank
+// lines and lines starting with '#' are ignored. Patterns are compiled to
+// match case-insensitively.
+func LoadPatterns(r io.Reader) ([]*regexp.Regexp, error) {
+	var patterns []*regexp.Regexp
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		pattern, err := regexp.Compile("(?i)" + text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, scanner.Err()
+}
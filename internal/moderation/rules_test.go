@@ -0,0 +1,177 @@
+package moderation
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		name string
+		rule Rule
+		post Post
+		want Verdict
+	}{
+		{
+			name: "Links under the limit",
+			rule: LinkLimit{Max: 2},
+			post: Post{Body: "see https://a.example and www.b.example"},
+			want: Allow,
+		},
+		{
+			name: "Links over the limit",
+			rule: LinkLimit{Max: 2},
+			post: Post{Body: "https://a.example http://b.example www.c.example"},
+			want: Reject,
+		},
+		{
+			name: "Short repeats are fine",
+			rule: RepeatedCharacters{Max: 5},
+			post: Post{Body: "soooo good!!!"},
+			want: Allow,
+		},
+		{
+			name: "Long repeats are flagged",
+			rule: RepeatedCharacters{Max: 5},
+			post: Post{Body: "sooooooooo good"},
+			want: Flag,
+		},
+		{
+			name: "Repeated multi-byte characters are flagged",
+			rule: RepeatedCharacters{Max: 3},
+			post: Post{Body: "🔥🔥🔥🔥"},
+			want: Flag,
+		},
+		{
+			name: "Repeated whitespace is ignored",
+			rule: RepeatedCharacters{Max: 3},
+			post: Post{Body: "a          b"},
+			want: Allow,
+		},
+		{
+			name: "Posting rate under the limit",
+			rule: PostingRate{Max: 5},
+			post: Post{RecentPosts: 4},
+			want: Allow,
+		},
+		{
+			name: "Posting rate at the limit",
+			rule: PostingRate{Max: 5},
+			post: Post{RecentPosts: 5},
+			want: Reject,
+		},
+		{
+			name: "New account without links",
+			rule: NewAccount{MinAge: time.Hour},
+			post: Post{Body: "hello", AccountAge: time.Minute},
+			want: Allow,
+		},
+		{
+			name: "New account with a link",
+			rule: NewAccount{MinAge: time.Hour},
+			post: Post{Body: "buy at https://spam.example", AccountAge: time.Minute},
+			want: Flag,
+		},
+		{
+			name: "Old account with a link",
+			rule: NewAccount{MinAge: time.Hour},
+			post: Post{Body: "read https://blog.example", AccountAge: 2 * time.Hour},
+			want: Allow,
+		},
+		{
+			name: "Deny pattern match",
+			rule: DenyPatterns{Patterns: []*regexp.Regexp{regexp.MustCompile(`(?i)free\s+crypto`)}},
+			post: Post{Body: "Get FREE  crypto now"},
+			want: Reject,
+		},
+		{
+			name: "Deny pattern miss",
+			rule: DenyPatterns{Patterns: []*regexp.Regexp{regexp.MustCompile(`(?i)free\s+crypto`)}},
+			post: Post{Body: "crypto is not free"},
+			want: Allow,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, reason := tt.rule.Check(tt.post)
+			if got != tt.want {
+				t.Errorf("Check() = %v, want %v", got, tt.want)
+			}
+			if (got == Allow) != (reason == "") {
+				t.Errorf("Check() reason = %q for verdict %v", reason, got)
+			}
+		})
+	}
+}
+
+func TestPipelineRun(t *testing.T) {
+	pipeline := Pipeline{Rules: []Rule{
+		LinkLimit{Max: 1},
+		RepeatedCharacters{Max: 3},
+		NewAccount{MinAge: time.Hour},
+	}}
+
+	tests := []struct {
+		name      string
+		post      Post
+		want      Verdict
+		wantRules []string
+	}{
+		{
+			name: "Clean post",
+			post: Post{Body: "hello", AccountAge: 2 * time.Hour},
+			want: Allow,
+		},
+		{
+			name:      "Flag only",
+			post:      Post{Body: "heyyyyy", AccountAge: 2 * time.Hour},
+			want:      Flag,
+			wantRules: []string{"repeated_characters"},
+		},
+		{
+			name:      "Most severe verdict wins",
+			post:      Post{Body: "https://a.example https://b.example", AccountAge: time.Minute},
+			want:      Reject,
+			wantRules: []string{"link_limit", "new_account"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, results := pipeline.Run(tt.post)
+			if got != tt.want {
+				t.Errorf("Run() = %v, want %v", got, tt.want)
+			}
+			var rules []string
+			for _, result := range results {
+				rules = append(rules, result.Rule)
+			}
+			if !reflect.DeepEqual(rules, tt.wantRules) {
+				t.Errorf("Run() rules = %v, want %v", rules, tt.wantRules)
+			}
+		})
+	}
+}
+
+func TestLoadPatterns(t *testing.T) {
+	input := "# spam\n\nfree\\s+crypto\n  click here  \n"
+	patterns, err := LoadPatterns(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("LoadPatterns() error = %v", err)
+	}
+	if len(patterns) != 2 {
+		t.Fatalf("LoadPatterns() returned %d patterns, want 2", len(patterns))
+	}
+	if !patterns[1].MatchString("CLICK HERE") {
+		t.Errorf("patterns should match case-insensitively")
+	}
+
+	_, err = LoadPatterns(strings.NewReader("ok\n(unclosed\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("LoadPatterns() error = %v, want an error on line 2", err)
+	}
+}
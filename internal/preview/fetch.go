@@ -0,0 +1,166 @@
+package preview
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/html/charset"
+)
+
+var (
+	ErrBlocked   = errors.New("address is not allowed")
+	ErrScheme    = errors.New("only http and https URLs can be previewed")
+	ErrRedirects = errors.New("too many redirects")
+	ErrNotHTML   = errors.New("response is not HTML")
+	ErrBadStatus = errors.New("unexpected response status")
+)
+
+// Limits bounds what a Fetcher will do for one preview.
+type Limits struct {
+	// Timeout covers the whole fetch, redirects included.
+	Timeout      time.Duration
+	MaxRedirects int
+	// MaxBytes is how much of the body is read. Meta tags live in the head,
+	// so a truncated page still yields its preview.
+	MaxBytes int64
+}
+
+// Fetcher fetches pages for previews. It only ever connects to public
+// addresses: the check runs on the address actually dialled, after DNS
+// resolution, so neither a hostname that resolves to a private address nor
+// a redirect to one gets through, and DNS answers cannot change between the
+// check and the connection.
+type Fetcher struct {
+	client *http.Client
+	limits Limits
+	// allowIP decides which addresses may be dialled. Tests replace it to
+	// reach their local servers.
+	allowIP func(netip.Addr) bool
+}
+
+// NewFetcher returns a Fetcher that enforces limits.
+func NewFetcher(limits Limits) *Fetcher {
+	f := &Fetcher{limits: limits, allowIP: publicAddr}
+
+	dialer := &net.Dialer{
+		Timeout: limits.Timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return fmt.Errorf("%w: %s", ErrBlocked, address)
+			}
+			if !f.allowIP(addrPort.Addr().Unmap()) {
+				return fmt.Errorf("%w: %s", ErrBlocked, addrPort.Addr())
+			}
+			return nil
+		},
+	}
+	transport := &http.Transport{
+		// A proxy would make the dialled address the proxy's, so the check
+		// above would no longer cover the destination.
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   limits.Timeout,
+		ResponseHeaderTimeout: limits.Timeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       30 * time.Second,
+	}
+	f.client = &http.Client{
+		Transport: transport,
+		Timeout:   limits.Timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > limits.MaxRedirects {
+				return ErrRedirects
+			}
+			return checkScheme(req.URL)
+		},
+	}
+	return f
+}
+
+// Fetch returns the preview of the page at rawURL.
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (Preview, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return Preview{}, err
+	}
+	if err := checkScheme(target); err != nil {
+		return Preview{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return Preview{}, err
+	}
+	req.Header.Set("Accept", "text/html")
+	req.Header.Set("User-Agent", "Chirpy-LinkPreview/1.0")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return Preview{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Preview{}, fmt.Errorf("%w: %s", ErrBadStatus, resp.Status)
+	}
+	contentType := resp.Header.Get("Content-Type")
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || (mediaType != "text/html" && mediaType != "application/xhtml+xml") {
+		return Preview{}, ErrNotHTML
+	}
+
+	// Pages in other encodings are decoded to UTF-8, going by the header or
+	// failing that the page's own <meta charset>.
+	body, err := charset.NewReader(io.LimitReader(resp.Body, f.limits.MaxBytes), contentType)
+	if err != nil {
+		return Preview{}, err
+	}
+
+	// The final URL after redirects is the base for relative image URLs.
+	return Parse(body, resp.Request.URL), nil
+}
+
+func checkScheme(u *url.URL) error {
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrScheme
+	}
+	return nil
+}
+
+// blockedPrefixes are special-purpose ranges that netip's predicates do not
+// cover but that must not be reachable either.
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("2001:db8::/32"),
+}
+
+// publicAddr reports whether addr is a public unicast address.
+func publicAddr(addr netip.Addr) bool {
+	if !addr.IsValid() || !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
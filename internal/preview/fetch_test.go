@@ -0,0 +1,245 @@
+package preview
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var testLimits = Limits{
+	Timeout:      2 * time.Second,
+	MaxRedirects: 2,
+	MaxBytes:     4096,
+}
+
+// loopbackFetcher is a Fetcher that may reach 127.0.0.1, where httptest
+// servers listen, and nothing else.
+func loopbackFetcher(limits Limits) *Fetcher {
+	f := NewFetcher(limits)
+	f.allowIP = func(addr netip.Addr) bool {
+		return addr == netip.MustParseAddr("127.0.0.1")
+	}
+	return f
+}
+
+func page(title string) string {
+	return `<html><head><meta property="og:title" content="` + title + `"></head><body></body></html>`
+}
+
+func TestFetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/page":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			fmt.Fprint(w, `<head><meta property="og:title" content="Remote"><meta property="og:image" content="/a.png"></head>`)
+		case "/moved":
+			http.Redirect(w, req, "/page", http.StatusFound)
+		}
+	}))
+	defer server.Close()
+
+	f := loopbackFetcher(testLimits)
+	for _, path := range []string{"/page", "/moved"} {
+		got, err := f.Fetch(context.Background(), server.URL+path)
+		if err != nil {
+			t.Fatalf("Fetch(%s) error = %v", path, err)
+		}
+		want := Preview{Title: "Remote", ImageURL: server.URL + "/a.png"}
+		if got != want {
+			t.Errorf("Fetch(%s) = %+v, want %+v", path, got, want)
+		}
+	}
+}
+
+func TestFetchDecodesCharset(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/header":
+			w.Header().Set("Content-Type", "text/html; charset=windows-1252")
+			fmt.Fprint(w, "<head><title>Caf\xe9 \x93quoted\x94</title></head>")
+		case "/meta":
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, `<head><meta charset="iso-8859-1"><title>Caf`+"\xe9 \xab\xbb</title></head>")
+		}
+	}))
+	defer server.Close()
+
+	f := loopbackFetcher(testLimits)
+	for path, want := range map[string]string{
+		"/header": "Café “quoted”",
+		"/meta":   "Café «»",
+	} {
+		got, err := f.Fetch(context.Background(), server.URL+path)
+		if err != nil {
+			t.Fatalf("Fetch(%s) error = %v", path, err)
+		}
+		if got.Title != want {
+			t.Errorf("Fetch(%s).Title = %q, want %q", path, got.Title, want)
+		}
+	}
+}
+
+func TestFetchBlocksPrivateAddresses(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, page("Internal"))
+	}))
+	defer server.Close()
+
+	f := NewFetcher(testLimits)
+	port := server.URL[strings.LastIndex(server.URL, ":"):]
+	for _, target := range []string{
+		server.URL,
+		"http://localhost" + port,
+		"http://[::1]" + port,
+		"http://0.0.0.0" + port,
+	} {
+		if _, err := f.Fetch(context.Background(), target); !errors.Is(err, ErrBlocked) {
+			t.Errorf("Fetch(%s) error = %v, want ErrBlocked", target, err)
+		}
+	}
+	if n := hits.Load(); n != 0 {
+		t.Errorf("server was reached %d times", n)
+	}
+}
+
+func TestFetchChecksRedirects(t *testing.T) {
+	var internalHits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/to-internal":
+			// 127.0.0.2 is loopback too, but not allowed by the test fetcher.
+			http.Redirect(w, req, "http://"+strings.Replace(req.Host, "127.0.0.1", "127.0.0.2", 1)+"/internal", http.StatusFound)
+		case "/to-javascript":
+			http.Redirect(w, req, "javascript:alert(1)", http.StatusFound)
+		case "/loop":
+			http.Redirect(w, req, "/loop", http.StatusFound)
+		case "/internal":
+			internalHits.Add(1)
+		}
+	}))
+	defer server.Close()
+
+	f := loopbackFetcher(testLimits)
+	tests := []struct {
+		path string
+		want error
+	}{
+		{path: "/to-internal", want: ErrBlocked},
+		{path: "/to-javascript", want: ErrScheme},
+		{path: "/loop", want: ErrRedirects},
+	}
+	for _, tt := range tests {
+		if _, err := f.Fetch(context.Background(), server.URL+tt.path); !errors.Is(err, tt.want) {
+			t.Errorf("Fetch(%s) error = %v, want %v", tt.path, err, tt.want)
+		}
+	}
+	if n := internalHits.Load(); n != 0 {
+		t.Errorf("internal address was reached %d times", n)
+	}
+}
+
+func TestFetchRejects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/json":
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{}`)
+		case "/missing":
+			http.NotFound(w, req)
+		}
+	}))
+	defer server.Close()
+
+	f := loopbackFetcher(testLimits)
+	tests := []struct {
+		url  string
+		want error
+	}{
+		{url: server.URL + "/json", want: ErrNotHTML},
+		{url: server.URL + "/missing", want: ErrBadStatus},
+		{url: "ftp://example.com/", want: ErrScheme},
+		{url: "javascript:alert(1)", want: ErrScheme},
+	}
+	for _, tt := range tests {
+		if _, err := f.Fetch(context.Background(), tt.url); !errors.Is(err, tt.want) {
+			t.Errorf("Fetch(%s) error = %v, want %v", tt.url, err, tt.want)
+		}
+	}
+}
+
+func TestFetchReadsAtMostMaxBytes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, "<head><!--"+strings.Repeat("x", int(testLimits.MaxBytes))+"-->")
+		fmt.Fprint(w, `<meta property="og:title" content="Too late"></head>`)
+	}))
+	defer server.Close()
+
+	got, err := loopbackFetcher(testLimits).Fetch(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if got.Title != "" {
+		t.Errorf("Title = %q, want the tag past MaxBytes to be ignored", got.Title)
+	}
+}
+
+func TestFetchTimesOut(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		select {
+		case <-done:
+		case <-req.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(done)
+
+	limits := testLimits
+	limits.Timeout = 50 * time.Millisecond
+	start := time.Now()
+	if _, err := loopbackFetcher(limits).Fetch(context.Background(), server.URL); err == nil {
+		t.Fatal("Fetch() error = nil, want a timeout")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Fetch() took %s", elapsed)
+	}
+}
+
+func TestPublicAddr(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"224.0.0.1", false},
+		{"255.255.255.255", false},
+	}
+	for _, tt := range tests {
+		if got := publicAddr(netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("publicAddr(%s) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+}
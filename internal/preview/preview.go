@@ -0,0 +1,167 @@
+// Package preview builds link preview cards from the OpenGraph and Twitter
+// card meta tags of remote pages, fetching them without letting a chirp
+// reach into the server's own network.
+package preview
+
+import (
+	"io"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Field lengths are capped so a hostile page cannot bloat every chirp that
+// links to it.
+const (
+	maxTitleLength       = 200
+	maxDescriptionLength = 500
+	maxImageURLLength    = 2048
+)
+
+// Preview is the card for a page. Any field may be empty.
+type Preview struct {
+	Title       string
+	Description string
+	ImageURL    string
+}
+
+// Empty reports whether the page had nothing to show.
+func (p Preview) Empty() bool {
+	return p.Title == "" && p.Description == "" && p.ImageURL == ""
+}
+
+// Parse reads the preview from the <head> of an HTML document. OpenGraph
+// tags win over Twitter card tags, which win over the plain <title> and
+// description. Relative image URLs are resolved against base, and images
+// that are not http or https are dropped. The document should already be
+// UTF-8; bytes that are not valid UTF-8 are replaced, so the fields are
+// always safe to store.
+func Parse(r io.Reader, base *url.URL) Preview {
+	var og, twitter, plain Preview
+	var inTitle bool
+
+	tokenizer := html.NewTokenizer(r)
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return finish(first(og, twitter, plain), base)
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			switch string(name) {
+			case "title":
+				inTitle = false
+			case "head":
+				return finish(first(og, twitter, plain), base)
+			}
+		case html.TextToken:
+			if inTitle && plain.Title == "" {
+				plain.Title = string(tokenizer.Text())
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := tokenizer.TagName()
+			switch string(name) {
+			case "title":
+				inTitle = true
+			case "body":
+				return finish(first(og, twitter, plain), base)
+			case "meta":
+				if !hasAttr {
+					continue
+				}
+				key, content := metaAttributes(tokenizer)
+				switch key {
+				case "og:title":
+					setOnce(&og.Title, content)
+				case "og:description":
+					setOnce(&og.Description, content)
+				case "og:image", "og:image:url", "og:image:secure_url":
+					setOnce(&og.ImageURL, content)
+				case "twitter:title":
+					setOnce(&twitter.Title, content)
+				case "twitter:description":
+					setOnce(&twitter.Description, content)
+				case "twitter:image", "twitter:image:src":
+					setOnce(&twitter.ImageURL, content)
+				case "description":
+					setOnce(&plain.Description, content)
+				}
+			}
+		}
+	}
+}
+
+// metaAttributes returns the property or name of a <meta> tag, lowercased,
+// and its content.
+func metaAttributes(tokenizer *html.Tokenizer) (key, content string) {
+	for {
+		name, value, more := tokenizer.TagAttr()
+		switch string(name) {
+		case "property", "name":
+			if key == "" {
+				key = strings.ToLower(strings.TrimSpace(string(value)))
+			}
+		case "content":
+			content = string(value)
+		}
+		if !more {
+			return key, content
+		}
+	}
+}
+
+func setOnce(field *string, value string) {
+	if *field == "" {
+		*field = value
+	}
+}
+
+// first merges previews field by field, taking each from the first preview
+// that has it.
+func first(previews ...Preview) Preview {
+	var merged Preview
+	for _, p := range previews {
+		setOnce(&merged.Title, clean(p.Title))
+		setOnce(&merged.Description, clean(p.Description))
+		setOnce(&merged.ImageURL, strings.TrimSpace(p.ImageURL))
+	}
+	return merged
+}
+
+func finish(p Preview, base *url.URL) Preview {
+	p.Title = truncate(p.Title, maxTitleLength)
+	p.Description = truncate(p.Description, maxDescriptionLength)
+	p.ImageURL = resolveImage(p.ImageURL, base)
+	return p
+}
+
+// clean collapses runs of whitespace, which pages often leave in titles, and
+// replaces invalid UTF-8, which Postgres will not store.
+func clean(s string) string {
+	return strings.Join(strings.Fields(strings.ToValidUTF8(s, "\uFFFD")), " ")
+}
+
+func truncate(s string, limit int) string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+	return strings.TrimSpace(string(runes[:limit-1])) + "…"
+}
+
+func resolveImage(ref string, base *url.URL) string {
+	if ref == "" || len(ref) > maxImageURLLength {
+		return ""
+	}
+	parsed, err := url.Parse(ref)
+	if err != nil {
+		return ""
+	}
+	if base != nil {
+		parsed = base.ResolveReference(parsed)
+	}
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return ""
+	}
+	return parsed.String()
+}
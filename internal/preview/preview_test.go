@@ -0,0 +1,105 @@
+package preview
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	base, _ := url.Parse("https://example.com/articles/1")
+
+	tests := []struct {
+		name string
+		html string
+		want Preview
+	}{
+		{
+			name: "OpenGraph tags",
+			html: `<html><head>
+				<meta property="og:title" content="A title">
+				<meta property="og:description" content="A description">
+				<meta property="og:image" content="https://cdn.example.com/a.png">
+			</head></html>`,
+			want: Preview{Title: "A title", Description: "A description", ImageURL: "https://cdn.example.com/a.png"},
+		},
+		{
+			name: "OpenGraph wins over Twitter and plain tags",
+			html: `<head>
+				<title>Plain</title>
+				<meta name="description" content="Plain description">
+				<meta name="twitter:title" content="Twitter">
+				<meta property="og:title" content="OpenGraph">
+			</head>`,
+			want: Preview{Title: "OpenGraph", Description: "Plain description"},
+		},
+		{
+			name: "Twitter card tags",
+			html: `<head>
+				<meta name="twitter:title" content="Card">
+				<meta name="twitter:image:src" content="/card.jpg">
+			</head>`,
+			want: Preview{Title: "Card", ImageURL: "https://example.com/card.jpg"},
+		},
+		{
+			name: "Plain title with whitespace",
+			html: "<head><title>\n  Hello\n  world  </title></head>",
+			want: Preview{Title: "Hello world"},
+		},
+		{
+			name: "Relative image is resolved",
+			html: `<meta property="og:image" content="../img/a.png">`,
+			want: Preview{ImageURL: "https://example.com/img/a.png"},
+		},
+		{
+			name: "Non-http image is dropped",
+			html: `<meta property="og:image" content="javascript:alert(1)">`,
+			want: Preview{},
+		},
+		{
+			name: "Entities are decoded",
+			html: `<meta property="og:title" content="Fish &amp; chips &lt;3">`,
+			want: Preview{Title: "Fish & chips <3"},
+		},
+		{
+			name: "Tags in the body are ignored",
+			html: `<head></head><body><meta property="og:title" content="Late"></body>`,
+			want: Preview{},
+		},
+		{
+			name: "Uppercase tags and keys",
+			html: `<HEAD><META PROPERTY="OG:TITLE" CONTENT="Loud"></HEAD>`,
+			want: Preview{Title: "Loud"},
+		},
+		{
+			name: "Invalid UTF-8 is replaced",
+			html: "<title>Caf\xe9</title>",
+			want: Preview{Title: "Caf\uFFFD"},
+		},
+		{
+			name: "Not HTML at all",
+			html: "just some text",
+			want: Preview{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Parse(strings.NewReader(tt.html), base)
+			if got != tt.want {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTruncatesLongFields(t *testing.T) {
+	html := `<meta property="og:title" content="` + strings.Repeat("a", 1000) + `">`
+	got := Parse(strings.NewReader(html), nil)
+	if n := len([]rune(got.Title)); n != maxTitleLength {
+		t.Errorf("len(Title) = %d, want %d", n, maxTitleLength)
+	}
+	if !strings.HasSuffix(got.Title, "…") {
+		t.Errorf("Title = %q, want an ellipsis", got.Title)
+	}
+}
@@ -0,0 +1,304 @@
+// Package render turns chirp bodies written with lightweight formatting into
+// HTML. Bodies are stored as written; *bold*, _italic_ and `code` are only
+// interpreted here, and entities such as links are passed in by the caller.
+//
+// The output is built from a fixed set of elements, so whatever the body
+// contains it can only ever produce <strong>, <em>, <code>, <br>, <mark> and
+// <a> with an http, https or site-relative href. Everything else is escaped.
+package render
+
+import (
+	"net/url"
+	"strings"
+	"unicode"
+)
+
+// Entity is a range of the body, in runes with End exclusive, that is
+// rendered as a link to Href and never formatted. An entity without an Href
+// is still kept away from formatting but rendered as plain text.
+type Entity struct {
+	Start int
+	End   int
+	Href  string
+}
+
+// HTML renders body as HTML. Overlapping or out-of-range entities are
+// ignored.
+func HTML(body string, entities []Entity) string {
+	r := newRenderer(body, entities, true)
+	r.render(0, len(r.runes))
+	return r.out.String()
+}
+
+// Text returns body with the formatting characters that HTML would interpret
+// removed, which is what a reader sees.
+func Text(body string, entities []Entity) string {
+	r := newRenderer(body, entities, false)
+	r.render(0, len(r.runes))
+	return r.out.String()
+}
+
+type renderer struct {
+	runes    []rune
+	entities map[int]Entity
+	// code maps the opening backtick of each code span to its closing one.
+	code map[int]int
+	// spans maps the opening * or _ of each formatting span to its closing
+	// one.
+	spans map[int]int
+	html  bool
+	out   strings.Builder
+}
+
+func newRenderer(body string, entities []Entity, html bool) *renderer {
+	r := &renderer{
+		runes:    []rune(body),
+		entities: make(map[int]Entity, len(entities)),
+		html:     html,
+	}
+	covered := make([]bool, len(r.runes))
+	for _, e := range entities {
+		if e.Start < 0 || e.End > len(r.runes) || e.Start >= e.End || overlaps(covered, e) {
+			continue
+		}
+		for i := e.Start; i < e.End; i++ {
+			covered[i] = true
+		}
+		r.entities[e.Start] = e
+	}
+	r.code = r.codeSpans()
+	r.spans = make(map[int]int)
+	r.delimiterSpans('*')
+	r.delimiterSpans('_')
+	return r
+}
+
+// codeSpans pairs up backticks from left to right, skipping entities. Code
+// spans are found before anything else, so formatting characters inside them
+// are left alone.
+func (r *renderer) codeSpans() map[int]int {
+	spans := make(map[int]int)
+	open := -1
+	for i := 0; i < len(r.runes); i++ {
+		if e, ok := r.entities[i]; ok {
+			i = e.End - 1
+			continue
+		}
+		if r.runes[i] != '`' {
+			continue
+		}
+		switch {
+		case open < 0:
+			open = i
+		case i == open+1:
+			// An empty span is just two backticks; the second may open.
+			open = i
+		default:
+			spans[open] = i
+			open = -1
+		}
+	}
+	return spans
+}
+
+func overlaps(covered []bool, e Entity) bool {
+	for i := e.Start; i < e.End; i++ {
+		if covered[i] {
+			return true
+		}
+	}
+	return false
+}
+
+// render writes runes[start:end].
+func (r *renderer) render(start, end int) {
+	for i := start; i < end; {
+		if e, ok := r.entities[i]; ok && e.End <= end {
+			r.entity(e)
+			i = e.End
+			continue
+		}
+
+		if close, ok := r.code[i]; ok && close < end {
+			r.tag("code", false)
+			r.text(i+1, close)
+			r.tag("code", true)
+			i = close + 1
+			continue
+		}
+
+		if close, ok := r.spans[i]; ok && close < end {
+			name := map[rune]string{'*': "strong", '_': "em"}[r.runes[i]]
+			r.tag(name, false)
+			r.render(i+1, close)
+			r.tag(name, true)
+			i = close + 1
+			continue
+		}
+
+		r.text(i, i+1)
+		i++
+	}
+}
+
+// delimiterSpans pairs up the delimiters c from left to right, skipping
+// entities and code spans, so a span can contain them but never cut one in
+// half. Each opener takes the first delimiter after it that can close it.
+// Spans must not be empty or start or end with a space, and an underscore
+// only counts at a word boundary so snake_case is left alone. Spans of one
+// kind never nest; spans of different kinds that cross are rejected by
+// render. All of this takes a single pass, however many delimiters never
+// close.
+func (r *renderer) delimiterSpans(c rune) {
+	open := -1
+	for i := 0; i < len(r.runes); i++ {
+		if e, ok := r.entities[i]; ok {
+			i = e.End - 1
+			continue
+		}
+		if close, ok := r.code[i]; ok {
+			i = close
+			continue
+		}
+		if r.runes[i] != c {
+			continue
+		}
+		switch {
+		case open >= 0 && r.closes(i, open):
+			r.spans[open] = i
+			open = -1
+		case open < 0 && r.opens(i):
+			open = i
+		}
+	}
+}
+
+func (r *renderer) opens(i int) bool {
+	if i+1 >= len(r.runes) || unicode.IsSpace(r.runes[i+1]) {
+		return false
+	}
+	return r.runes[i] != '_' || i == 0 || !isWordRune(r.runes[i-1])
+}
+
+func (r *renderer) closes(j, open int) bool {
+	if j == open+1 || unicode.IsSpace(r.runes[j-1]) {
+		return false
+	}
+	return r.runes[j] != '_' || j+1 >= len(r.runes) || !isWordRune(r.runes[j+1])
+}
+
+func (r *renderer) entity(e Entity) {
+	href, ok := safeHref(e.Href)
+	if !ok || !r.html {
+		r.text(e.Start, e.End)
+		return
+	}
+	r.out.WriteString(`<a href="`)
+	r.out.WriteString(escape(href))
+	r.out.WriteString(`" rel="nofollow noopener noreferrer">`)
+	r.text(e.Start, e.End)
+	r.out.WriteString("</a>")
+}
+
+func (r *renderer) tag(name string, closing bool) {
+	if !r.html {
+		return
+	}
+	r.out.WriteByte('<')
+	if closing {
+		r.out.WriteByte('/')
+	}
+	r.out.WriteString(name)
+	r.out.WriteByte('>')
+}
+
+// text writes runes[start:end] as text.
+func (r *renderer) text(start, end int) {
+	s := string(r.runes[start:end])
+	if !r.html {
+		r.out.WriteString(s)
+		return
+	}
+	r.out.WriteString(escape(s))
+}
+
+// escaper escapes text for use in element content and quoted attribute
+// values. Line feeds become line breaks; carriage returns are dropped so a
+// CRLF makes a single break.
+var escaper = strings.NewReplacer(
+	"&", "&amp;",
+	"<", "&lt;",
+	">", "&gt;",
+	`"`, "&#34;",
+	"'", "&#39;",
+	"\x00", "\uFFFD",
+	"\r", "",
+	"\n", "<br>",
+)
+
+func escape(s string) string {
+	return escaper.Replace(s)
+}
+
+// safeHref returns href if it is an absolute http or https URL or a path on
+// this site.
+func safeHref(href string) (string, bool) {
+	if href == "" {
+		return "", false
+	}
+	parsed, err := url.Parse(href)
+	if err != nil {
+		return "", false
+	}
+	switch {
+	case parsed.Scheme == "http" || parsed.Scheme == "https":
+		if parsed.Host == "" {
+			return "", false
+		}
+	case parsed.Scheme == "" && parsed.Host == "" && strings.HasPrefix(href, "/") && !strings.HasPrefix(href, "//") && !strings.Contains(href, `\`):
+	default:
+		return "", false
+	}
+	return parsed.String(), true
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.M, r) || r == '_'
+}
+
+// Delimiters of the matches in text passed to Highlight. They are control
+// characters, which chirp bodies have no use for.
+const (
+	HighlightStart = "\x02"
+	HighlightEnd   = "\x03"
+)
+
+// Highlight renders text, such as a search snippet, as HTML with each match
+// between HighlightStart and HighlightEnd wrapped in <mark>. Delimiters that
+// do not pair up are dropped. Formatting is not interpreted.
+func Highlight(text string) string {
+	var out strings.Builder
+	marked := false
+	for len(text) > 0 {
+		i := strings.IndexAny(text, HighlightStart+HighlightEnd)
+		if i < 0 {
+			out.WriteString(escape(text))
+			break
+		}
+		out.WriteString(escape(text[:i]))
+		switch text[i : i+1] {
+		case HighlightStart:
+			if !marked && strings.Contains(text[i+1:], HighlightEnd) {
+				out.WriteString("<mark>")
+				marked = true
+			}
+		case HighlightEnd:
+			if marked {
+				out.WriteString("</mark>")
+				marked = false
+			}
+		}
+		text = text[i+1:]
+	}
+	return out.String()
+}
@@ -0,0 +1,256 @@
+package render
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+func TestHTML(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		entities []Entity
+		want     string
+	}{
+		{
+			name: "Plain text",
+			body: "hello world",
+			want: "hello world",
+		},
+		{
+			name: "Bold, italic and code",
+			body: "*bold* _italic_ `code`",
+			want: "<strong>bold</strong> <em>italic</em> <code>code</code>",
+		},
+		{
+			name: "Nested formatting",
+			body: "*very _much_ so*",
+			want: "<strong>very <em>much</em> so</strong>",
+		},
+		{
+			name: "Formatting is literal inside code",
+			body: "`*not bold*`",
+			want: "<code>*not bold*</code>",
+		},
+		{
+			name: "Unmatched delimiters are literal",
+			body: "2 * 3 and *half",
+			want: "2 * 3 and *half",
+		},
+		{
+			name: "Spans cannot start or end with a space",
+			body: "* no * *no *",
+			want: "* no * *no *",
+		},
+		{
+			name: "Underscores inside words are literal",
+			body: "snake_case_name",
+			want: "snake_case_name",
+		},
+		{
+			name: "Empty spans are literal",
+			body: "** __ ``",
+			want: "** __ ``",
+		},
+		{
+			name: "HTML is escaped",
+			body: `<script>alert("x")</script> & 'y'`,
+			want: "&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt; &amp; &#39;y&#39;",
+		},
+		{
+			name: "HTML is escaped inside formatting",
+			body: "*<b>* `<i>`",
+			want: "<strong>&lt;b&gt;</strong> <code>&lt;i&gt;</code>",
+		},
+		{
+			name: "Newlines become line breaks",
+			body: "one\ntwo",
+			want: "one<br>two",
+		},
+		{
+			name:     "Entities become links",
+			body:     "hi @bob see #go",
+			entities: []Entity{{Start: 3, End: 7, Href: "/users/1"}, {Start: 12, End: 15, Href: "https://example.com/tags/go"}},
+			want:     `hi <a href="/users/1" rel="nofollow noopener noreferrer">@bob</a> see <a href="https://example.com/tags/go" rel="nofollow noopener noreferrer">#go</a>`,
+		},
+		{
+			name:     "Formatting can wrap an entity",
+			body:     "*@bob*",
+			entities: []Entity{{Start: 1, End: 5, Href: "/users/1"}},
+			want:     `<strong><a href="/users/1" rel="nofollow noopener noreferrer">@bob</a></strong>`,
+		},
+		{
+			name:     "Formatting never splits an entity",
+			body:     "_see https://example.com/a_b and_",
+			entities: []Entity{{Start: 5, End: 28, Href: "https://example.com/a_b"}},
+			want:     `<em>see <a href="https://example.com/a_b" rel="nofollow noopener noreferrer">https://example.com/a_b</a> and</em>`,
+		},
+		{
+			name:     "Entity without an href is plain text",
+			body:     "@nobody_here_",
+			entities: []Entity{{Start: 0, End: 12}},
+			want:     "@nobody_here_",
+		},
+		{
+			name:     "Unsafe hrefs are not linked",
+			body:     "a b c d",
+			entities: []Entity{{Start: 0, End: 1, Href: "javascript:alert(1)"}, {Start: 2, End: 3, Href: "//evil.example"}, {Start: 4, End: 5, Href: "data:text/html,x"}, {Start: 6, End: 7, Href: "/\\evil.example"}},
+			want:     "a b c d",
+		},
+		{
+			name:     "Hrefs are escaped",
+			body:     "x",
+			entities: []Entity{{Start: 0, End: 1, Href: `https://example.com/"><script>`}},
+			want:     `<a href="https://example.com/%22%3E%3Cscript%3E" rel="nofollow noopener noreferrer">x</a>`,
+		},
+		{
+			name:     "Bad entities are ignored",
+			body:     "abc",
+			entities: []Entity{{Start: 2, End: 10, Href: "/x"}, {Start: 1, End: 0, Href: "/x"}, {Start: -1, End: 1, Href: "/x"}},
+			want:     "abc",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HTML(tt.body, tt.entities); got != tt.want {
+				t.Errorf("HTML() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestText(t *testing.T) {
+	tests := []struct {
+		body string
+		want string
+	}{
+		{body: "*bold* _italic_ `code`", want: "bold italic code"},
+		{body: "`*literal*`", want: "*literal*"},
+		{body: "2 * 3 = 6", want: "2 * 3 = 6"},
+		{body: "snake_case", want: "snake_case"},
+	}
+	for _, tt := range tests {
+		if got := Text(tt.body, nil); got != tt.want {
+			t.Errorf("Text(%q) = %q, want %q", tt.body, got, tt.want)
+		}
+	}
+}
+
+var allowedTags = map[string]bool{"strong": true, "em": true, "code": true, "br": true, "a": true}
+
+// FuzzHTML checks that no body or entity can get anything past the
+// whitelist: the output parses back into allowed elements only, links carry
+// nothing but a safe href and rel, and the text a reader sees is exactly
+// what Text reports.
+func FuzzHTML(f *testing.F) {
+	seeds := []string{
+		"*bold* _italic_ `code`",
+		`<script>alert(1)</script>`,
+		`<img src=x onerror=alert(1)>`,
+		`"><svg onload=alert(1)>`,
+		"*<a href=`javascript:alert(1)`>x</a>*",
+		"_`*_*`_",
+		"&lt;script&gt; &#60;",
+		"line\nbreak\r\n\x00",
+		"<!-- comment --> <![CDATA[x]]>",
+	}
+	for _, seed := range seeds {
+		f.Add(seed, 0, 3, "https://example.com/")
+		f.Add(seed, 1, 4, "javascript:alert(1)")
+	}
+
+	f.Fuzz(func(t *testing.T, body string, start, end int, href string) {
+		out := HTML(body, []Entity{{Start: start, End: end, Href: href}})
+
+		var text strings.Builder
+		tokenizer := html.NewTokenizer(strings.NewReader(out))
+		for {
+			tt := tokenizer.Next()
+			if tt == html.ErrorToken {
+				break
+			}
+			token := tokenizer.Token()
+			switch tt {
+			case html.TextToken:
+				text.WriteString(token.Data)
+			case html.StartTagToken, html.EndTagToken, html.SelfClosingTagToken:
+				if !allowedTags[token.Data] {
+					t.Fatalf("HTML(%q) produced <%s>: %s", body, token.Data, out)
+				}
+				if token.Data == "br" {
+					text.WriteString("\n")
+				}
+				for _, attr := range token.Attr {
+					switch {
+					case token.Data == "a" && attr.Key == "rel":
+					case token.Data == "a" && attr.Key == "href":
+						if _, ok := safeHref(attr.Val); !ok {
+							t.Fatalf("HTML(%q) produced unsafe href %q: %s", body, attr.Val, out)
+						}
+					default:
+						t.Fatalf("HTML(%q) produced attribute %s on <%s>: %s", body, attr.Key, token.Data, out)
+					}
+				}
+			default:
+				t.Fatalf("HTML(%q) produced a %s token: %s", body, tt, out)
+			}
+		}
+
+		want := strings.NewReplacer("\r", "", "\x00", "\uFFFD").Replace(Text(body, []Entity{{Start: start, End: end, Href: href}}))
+		if text.String() != want {
+			t.Fatalf("HTML(%q) reads as %q, want %q", body, text.String(), want)
+		}
+	})
+}
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{text: "no matches", want: "no matches"},
+		{text: "a \x02match\x03 and \x02another\x03", want: "a <mark>match</mark> and <mark>another</mark>"},
+		{text: "\x02<b>\x03 & *x*", want: "<mark>&lt;b&gt;</mark> &amp; *x*"},
+		{text: "unpaired \x02start", want: "unpaired start"},
+		{text: "unpaired end\x03", want: "unpaired end"},
+		{text: "\x02\x02nested\x03\x03", want: "<mark>nested</mark>"},
+	}
+	for _, tt := range tests {
+		if got := Highlight(tt.text); got != tt.want {
+			t.Errorf("Highlight(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+// unclosedDelimiters are bodies full of delimiters that never close, which
+// must render in time linear in their length.
+var unclosedDelimiters = []string{
+	strings.Repeat("*a ", 40000),
+	strings.Repeat("_a ", 40000),
+	strings.Repeat("*_a ", 30000),
+	strings.Repeat("*a _b ", 20000) + "`",
+}
+
+func TestTextUnclosedDelimiters(t *testing.T) {
+	for _, body := range unclosedDelimiters {
+		start := time.Now()
+		if got := Text(body, nil); got != body {
+			t.Errorf("Text() changed a body of %d unclosed delimiters", len(body))
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("Text() took %s on a %d byte body", elapsed, len(body))
+		}
+	}
+}
+
+func BenchmarkTextUnclosedDelimiters(b *testing.B) {
+	for range b.N {
+		for _, body := range unclosedDelimiters {
+			Text(body, nil)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+// Package search parses the query language of chirp search into the pieces
+// the database needs: a PostgreSQL tsquery and the operators that filter
+// results directly.
+package search
+
+import (
+	"chirpy/internal/entities"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+const (
+	maxQueryLength = 500
+	maxTerms       = 20
+	maxTags        = 5
+)
+
+var (
+	ErrEmpty    = errors.New("search query is empty")
+	ErrTooLong  = errors.New("search query is too long")
+	ErrBadQuery = errors.New("search query is invalid")
+)
+
+// Query is a parsed search query.
+type Query struct {
+	// TSQuery is in to_tsquery syntax, or empty when the query has no text
+	// terms and is only filtered by operators. Every lexeme in it consists
+	// of letters, marks and digits only, so it always parses.
+	TSQuery string
+	// From is the handle given with from:, if any.
+	From string
+	// Tags are the normalised hashtags given with #, all of which must be
+	// present.
+	Tags []string
+}
+
+// Parse parses q. Words must all match, in any form the text search
+// configuration considers equivalent. "Quoted words" must appear next to
+// each other in that order, and a word ending in * matches any word it
+// prefixes. from:handle restricts results to one author and #tag to chirps
+// with that hashtag.
+func Parse(q string) (Query, error) {
+	if len(q) > maxQueryLength {
+		return Query{}, ErrTooLong
+	}
+
+	var query Query
+	var terms []string
+	for _, token := range tokenize(q) {
+		switch {
+		case token.phrase:
+			if phrase := phraseTerm(token.text); phrase != "" {
+				terms = append(terms, phrase)
+			}
+		case strings.HasPrefix(strings.ToLower(token.text), "from:"):
+			handle := strings.TrimPrefix(token.text[len("from:"):], "@")
+			if !entities.ValidHandle(handle) {
+				return Query{}, fmt.Errorf("%w: from: needs a handle", ErrBadQuery)
+			}
+			if query.From != "" && query.From != handle {
+				return Query{}, fmt.Errorf("%w: only one from: is allowed", ErrBadQuery)
+			}
+			query.From = handle
+		case strings.HasPrefix(token.text, "#"):
+			tags := entities.Hashtags(token.text)
+			if len(tags) != 1 || tags[0].Start != 0 || tags[0].End != len([]rune(token.text)) {
+				return Query{}, fmt.Errorf("%w: invalid hashtag %s", ErrBadQuery, token.text)
+			}
+			query.Tags = appendUnique(query.Tags, tags[0].Tag)
+		default:
+			if term := wordTerm(token.text); term != "" {
+				terms = append(terms, term)
+			}
+		}
+	}
+
+	if len(terms) > maxTerms {
+		return Query{}, fmt.Errorf("%w: too many search terms", ErrBadQuery)
+	}
+	if len(query.Tags) > maxTags {
+		return Query{}, fmt.Errorf("%w: too many hashtags", ErrBadQuery)
+	}
+	query.TSQuery = strings.Join(terms, " & ")
+	if query.TSQuery == "" && query.From == "" && len(query.Tags) == 0 {
+		return Query{}, ErrEmpty
+	}
+	return query, nil
+}
+
+type token struct {
+	text   string
+	phrase bool
+}
+
+// tokenize splits q on whitespace, keeping double-quoted runs together. An
+// unterminated quote runs to the end of q.
+func tokenize(q string) []token {
+	var tokens []token
+	var current strings.Builder
+	inQuote := false
+	flush := func(phrase bool) {
+		if current.Len() > 0 || phrase {
+			tokens = append(tokens, token{text: current.String(), phrase: phrase})
+		}
+		current.Reset()
+	}
+
+	for _, r := range q {
+		switch {
+		case r == '"':
+			flush(inQuote)
+			inQuote = !inQuote
+		case unicode.IsSpace(r) && !inQuote:
+			flush(false)
+		default:
+			current.WriteRune(r)
+		}
+	}
+	flush(inQuote)
+	return tokens
+}
+
+// lexemes splits s into runs of letters, marks and digits, dropping
+// everything that to_tsquery would treat as syntax.
+func lexemes(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.Is(unicode.M, r) && !unicode.IsDigit(r)
+	})
+}
+
+// wordTerm turns a word into a tsquery term. A word that punctuation splits
+// into several lexemes, such as "e-mail", becomes a phrase of them.
+func wordTerm(word string) string {
+	prefix := strings.HasSuffix(word, "*")
+	parts := lexemes(word)
+	if len(parts) == 0 {
+		return ""
+	}
+	if prefix {
+		parts[len(parts)-1] += ":*"
+	}
+	return group(parts)
+}
+
+func phraseTerm(phrase string) string {
+	parts := lexemes(phrase)
+	if len(parts) == 0 {
+		return ""
+	}
+	return group(parts)
+}
+
+func group(parts []string) string {
+	if len(parts) == 1 {
+		return parts[0]
+	}
+	return "(" + strings.Join(parts, " <-> ") + ")"
+}
+
+func appendUnique(list []string, s string) []string {
+	for _, existing := range list {
+		if existing == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
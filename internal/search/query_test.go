@@ -0,0 +1,110 @@
+package search
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		q       string
+		want    Query
+		wantErr error
+	}{
+		{
+			name: "Words must all match",
+			q:    "hello world",
+			want: Query{TSQuery: "hello & world"},
+		},
+		{
+			name: "Quoted phrase",
+			q:    `"hello world" again`,
+			want: Query{TSQuery: "(hello <-> world) & again"},
+		},
+		{
+			name: "Unterminated quote runs to the end",
+			q:    `say "hello world`,
+			want: Query{TSQuery: "say & (hello <-> world)"},
+		},
+		{
+			name: "Prefix",
+			q:    "prog*",
+			want: Query{TSQuery: "prog:*"},
+		},
+		{
+			name: "Punctuation inside a word makes a phrase",
+			q:    "e-mail",
+			want: Query{TSQuery: "(e <-> mail)"},
+		},
+		{
+			name: "tsquery syntax is dropped",
+			q:    `a&b | !c (d) 'e':* <->`,
+			want: Query{TSQuery: "(a <-> b) & c & d & e:*"},
+		},
+		{
+			name: "Unicode words",
+			q:    "café 日本語",
+			want: Query{TSQuery: "café & 日本語"},
+		},
+		{
+			name: "from and hashtags",
+			q:    "from:@alice #Go #go news",
+			want: Query{TSQuery: "news", From: "alice", Tags: []string{"go"}},
+		},
+		{
+			name: "Hashtags are folded and composed",
+			q:    "#STRASSE #straße #Cafe\u0301",
+			want: Query{Tags: []string{"strasse", "café"}},
+		},
+		{
+			name: "Operators alone",
+			q:    "FROM:bob",
+			want: Query{From: "bob"},
+		},
+		{
+			name:    "Empty",
+			q:       "  \"\" !! ",
+			wantErr: ErrEmpty,
+		},
+		{
+			name:    "Bad handle",
+			q:       "from:not-a-handle",
+			wantErr: ErrBadQuery,
+		},
+		{
+			name:    "Two authors",
+			q:       "from:alice from:bob",
+			wantErr: ErrBadQuery,
+		},
+		{
+			name:    "Bad hashtag",
+			q:       "#123",
+			wantErr: ErrBadQuery,
+		},
+		{
+			name:    "Too long",
+			q:       strings.Repeat("a", maxQueryLength+1),
+			wantErr: ErrTooLong,
+		},
+		{
+			name:    "Too many terms",
+			q:       strings.Repeat("a ", maxTerms+1),
+			wantErr: ErrBadQuery,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.q)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Parse() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+// Package trending ranks hashtags by time-decayed usage.
+package trending
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Usage is the number of times a tag was used within a time bucket starting
+// at Bucket.
+type Usage struct {
+	Tag    string
+	Bucket time.Time
+	Count  int64
+}
+
+type Score struct {
+	Tag   string
+	Score float64
+	Uses  int64
+}
+
+// Rank scores every tag in usage and returns the top limit tags, highest
+// score first. Each use contributes 0.5^(age/halfLife) to its tag's score, so
+// a use halfLife old counts half as much as one made now. Ties are broken by
+// tag name to keep the ordering stable.
+func Rank(usage []Usage, now time.Time, halfLife time.Duration, limit int) []Score {
+	byTag := make(map[string]*Score)
+	for _, u := range usage {
+		age := now.Sub(u.Bucket)
+		if age < 0 {
+			age = 0
+		}
+		weight := math.Pow(0.5, age.Hours()/halfLife.Hours())
+
+		score, ok := byTag[u.Tag]
+		if !ok {
+			score = &Score{Tag: u.Tag}
+			byTag[u.Tag] = score
+		}
+		score.Score += float64(u.Count) * weight
+		score.Uses += u.Count
+	}
+
+	scores := make([]Score, 0, len(byTag))
+	for _, score := range byTag {
+		scores = append(scores, *score)
+	}
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].Score != scores[j].Score {
+			return scores[i].Score > scores[j].Score
+		}
+		return scores[i].Tag < scores[j].Tag
+	})
+
+	if limit >= 0 && len(scores) > limit {
+		scores = scores[:limit]
+	}
+	return scores
+}
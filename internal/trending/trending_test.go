@@ -0,0 +1,53 @@
+package trending
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestRank(t *testing.T) {
+	now := time.Date(2025, 1, 2, 12, 0, 0, 0, time.UTC)
+	halfLife := 6 * time.Hour
+
+	usage := []Usage{
+		{Tag: "old", Bucket: now.Add(-12 * time.Hour), Count: 10},
+		{Tag: "fresh", Bucket: now, Count: 3},
+		{Tag: "steady", Bucket: now.Add(-6 * time.Hour), Count: 2},
+		{Tag: "steady", Bucket: now, Count: 1},
+	}
+
+	got := Rank(usage, now, halfLife, 10)
+
+	wantOrder := []string{"fresh", "old", "steady"}
+	if len(got) != len(wantOrder) {
+		t.Fatalf("Rank() returned %d tags, want %d", len(got), len(wantOrder))
+	}
+	for i, tag := range wantOrder {
+		if got[i].Tag != tag {
+			t.Errorf("Rank()[%d] = %s, want %s", i, got[i].Tag, tag)
+		}
+	}
+
+	// 10 uses two half-lives ago are worth 2.5 uses now.
+	if math.Abs(got[1].Score-2.5) > 1e-9 {
+		t.Errorf("Rank() old score = %v, want 2.5", got[1].Score)
+	}
+	if got[2].Uses != 3 {
+		t.Errorf("Rank() steady uses = %d, want 3", got[2].Uses)
+	}
+}
+
+func TestRankLimit(t *testing.T) {
+	now := time.Now()
+	usage := []Usage{
+		{Tag: "a", Bucket: now, Count: 1},
+		{Tag: "b", Bucket: now, Count: 2},
+		{Tag: "c", Bucket: now, Count: 3},
+	}
+
+	got := Rank(usage, now, time.Hour, 2)
+	if len(got) != 2 || got[0].Tag != "c" || got[1].Tag != "b" {
+		t.Errorf("Rank() = %v, want [c b]", got)
+	}
+}
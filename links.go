@@ -0,0 +1,182 @@
+package main
+
+import (
+	"chirpy/internal/chirptext"
+	"chirpy/internal/database"
+	"chirpy/internal/entities"
+	"chirpy/internal/render"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// linkLength is what every shortened URL in a chirp counts for against
+	// the length limit, however long it is.
+	linkLength = 23
+	// maxLinkURLLength bounds the URLs that are shortened. Longer ones are
+	// left as plain text.
+	maxLinkURLLength = 2048
+	linkCodeLength   = 7
+	linkCodeAlphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	// linkCodeAttempts bounds how often a colliding code is regenerated.
+	linkCodeAttempts = 5
+)
+
+// chirpLength is the length of body as counted against the chirp length
+// limit: the user-perceived characters a reader sees once formatting is
+// rendered, with each link that gets shortened counted as linkLength. Links
+// too long to shorten are shown in full, so they count in full too.
+func chirpLength(body string) int {
+	length := chirptext.Length(render.Text(body, bodyEntities(body)))
+	for _, link := range entities.URLs(body) {
+		if len(link.URL) > maxLinkURLLength {
+			continue
+		}
+		length += linkLength - chirptext.Length(link.URL)
+	}
+	return length
+}
+
+func linkURL(code string) string {
+	return "/l/" + code
+}
+
+// linkURLs returns the distinct URLs in body that are short enough to be
+// shortened.
+func linkURLs(body string) []string {
+	seen := map[string]bool{}
+	var urls []string
+	for _, link := range entities.URLs(body) {
+		if len(link.URL) > maxLinkURLLength || seen[link.URL] {
+			continue
+		}
+		seen[link.URL] = true
+		urls = append(urls, link.URL)
+	}
+	return urls
+}
+
+func newLinkCode() (string, error) {
+	var code strings.Builder
+	size := big.NewInt(int64(len(linkCodeAlphabet)))
+	for range linkCodeLength {
+		n, err := rand.Int(rand.Reader, size)
+		if err != nil {
+			return "", err
+		}
+		code.WriteByte(linkCodeAlphabet[n.Int64()])
+	}
+	return code.String(), nil
+}
+
+// saveChirpLinks gives every URL in the chirp body a short code. A URL that
+// has been linked before keeps its code, so clicks are counted per URL rather
+// than per chirp.
+func saveChirpLinks(ctx context.Context, q *database.Queries, chirp database.Chirp) error {
+	for _, u := range linkURLs(chirp.Body) {
+		if err := saveLink(ctx, q, u); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// saveLink inserts u with a fresh code unless it already has one. Conflicts
+// are skipped rather than raised so that a lost race, on the URL or on the
+// code, does not abort the surrounding transaction.
+func saveLink(ctx context.Context, q *database.Queries, u string) error {
+	for range linkCodeAttempts {
+		code, err := newLinkCode()
+		if err != nil {
+			return err
+		}
+		_, err = q.CreateLink(ctx, database.CreateLinkParams{Code: code, Url: u})
+		if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		_, err = q.GetLinkByURL(ctx, u)
+		if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		// The code was taken; try another.
+	}
+	return errors.New("could not generate a unique link code")
+}
+
+// chirpLink is what is known about a shortened URL when building chirps.
+type chirpLink struct {
+	code    string
+	preview *LinkPreview
+}
+
+// chirpLinks loads the short code and preview of each shortened URL in the
+// given chirps, keyed by URL.
+func (cfg *apiConfig) chirpLinks(ctx context.Context, chirps []database.Chirp) (map[string]chirpLink, error) {
+	var urls []string
+	for _, chirp := range chirps {
+		urls = append(urls, linkURLs(chirp.Body)...)
+	}
+	result := make(map[string]chirpLink, len(urls))
+	if len(urls) == 0 {
+		return result, nil
+	}
+
+	links, err := cfg.db.GetLinksByURLs(ctx, urls)
+	if err != nil {
+		return nil, err
+	}
+	if len(links) == 0 {
+		return result, nil
+	}
+	linkIDs := make([]uuid.UUID, len(links))
+	for i, link := range links {
+		linkIDs[i] = link.ID
+	}
+	previews, err := cfg.db.GetLinkPreviews(ctx, linkIDs)
+	if err != nil {
+		return nil, err
+	}
+	cards := make(map[uuid.UUID]*LinkPreview, len(previews))
+	for _, p := range previews {
+		if p.Title == "" && p.Description == "" && p.ImageUrl == "" {
+			continue
+		}
+		cards[p.LinkID] = &LinkPreview{Title: p.Title, Description: p.Description, ImageURL: p.ImageUrl}
+	}
+
+	for _, link := range links {
+		result[link.Url] = chirpLink{code: link.Code, preview: cards[link.ID]}
+	}
+	return result, nil
+}
+
+// follow_link redirects to the URL behind a short code and counts the click.
+// Only http and https URLs are followed, whatever ended up in the table.
+func (cfg *apiConfig) follow_link(w http.ResponseWriter, req *http.Request) {
+	target, err := cfg.db.FollowLink(req.Context(), req.PathValue("code"))
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Not found", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not follow link", err)
+		return
+	}
+
+	parsed, err := url.Parse(target)
+	if err != nil || (!strings.EqualFold(parsed.Scheme, "http") && !strings.EqualFold(parsed.Scheme, "https")) || parsed.Host == "" {
+		respondWithError(w, http.StatusBadRequest, "Refusing to redirect to this URL", err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	http.Redirect(w, req, parsed.String(), http.StatusFound)
+}
@@ -2,12 +2,19 @@ package main
 
 import (
 	"chirpy/internal/database"
+	"chirpy/internal/media"
+	"chirpy/internal/moderation"
+	"chirpy/internal/preview"
+	"context"
 	"database/sql"
 	"io"
 	"log"
 	"net/http"
 	"os"
+	"regexp"
+	"strconv"
 	"sync/atomic"
+	"time"
 
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
@@ -16,8 +23,17 @@ import (
 type apiConfig struct {
 	fileserverHits atomic.Int32
 	db             *database.Queries
+	dbConn         *sql.DB
 	platform       string
 	secret         string
+	trending       trendingTags
+	editWindow     time.Duration
+	pinLimit       int
+	filter         *moderation.Filter
+	chirpLimits    map[string]int
+	automod        moderation.Pipeline
+	storage        media.Storage
+	previews       *preview.Fetcher
 }
 
 func main() {
@@ -41,6 +57,50 @@ func main() {
 		log.Fatal("PLATFORM is required")
 	}
 
+	editWindow := 15 * time.Minute
+	if window := os.Getenv("CHIRP_EDIT_WINDOW"); window != "" {
+		parsed, err := time.ParseDuration(window)
+		if err != nil {
+			log.Fatalf("CHIRP_EDIT_WINDOW must be a duration: %s", err)
+		}
+		editWindow = parsed
+	}
+
+	pinLimit := 3
+	if limit := os.Getenv("PINNED_CHIRPS_LIMIT"); limit != "" {
+		parsed, err := strconv.Atoi(limit)
+		if err != nil || parsed < 0 {
+			log.Fatal("PINNED_CHIRPS_LIMIT must be a non-negative integer")
+		}
+		pinLimit = parsed
+	}
+
+	chirpLimitsEnv := os.Getenv("CHIRP_LIMITS")
+	if chirpLimitsEnv == "" {
+		chirpLimitsEnv = "standard=140"
+	}
+	chirpLimits, err := parseChirpLimits(chirpLimitsEnv)
+	if err != nil {
+		log.Fatalf("CHIRP_LIMITS is invalid: %s", err)
+	}
+
+	var denyPatterns []*regexp.Regexp
+	if patternsFile := os.Getenv("MODERATION_PATTERNS_FILE"); patternsFile != "" {
+		denyPatterns, err = loadDenyPatterns(patternsFile)
+		if err != nil {
+			log.Fatalf("Could not load MODERATION_PATTERNS_FILE: %s", err)
+		}
+	}
+
+	mediaDir := os.Getenv("MEDIA_DIR")
+	if mediaDir == "" {
+		mediaDir = "media"
+	}
+	storage, err := media.NewLocal(mediaDir)
+	if err != nil {
+		log.Fatalf("Could not use MEDIA_DIR: %s", err)
+	}
+
 	dbconn, err := sql.Open("postgres", dbURL)
 
 	if err != nil {
@@ -52,15 +112,53 @@ func main() {
 	var apiCfg = apiConfig{
 		fileserverHits: atomic.Int32{},
 		db:             dbQueries,
+		dbConn:         dbconn,
 		platform:       platform,
 		secret:         secret,
+		editWindow:     editWindow,
+		pinLimit:       pinLimit,
+		filter:         moderation.NewFilter(nil),
+		chirpLimits:    chirpLimits,
+		automod:        newAutomod(denyPatterns),
+		storage:        storage,
+		previews:       preview.NewFetcher(previewLimits),
 	}
 
+	if wordsFile := os.Getenv("MODERATION_WORDS_FILE"); wordsFile != "" {
+		if err := apiCfg.seedBannedWords(context.Background(), wordsFile); err != nil {
+			log.Fatalf("Could not load MODERATION_WORDS_FILE: %s", err)
+		}
+	}
+	if err := apiCfg.reloadBannedWords(context.Background()); err != nil {
+		log.Fatalf("Could not load banned words: %s", err)
+	}
+
+	go apiCfg.runTrending(context.Background())
+	go apiCfg.runBannedWordsReload(context.Background())
+	go apiCfg.runPurge(context.Background())
+	go apiCfg.runPublisher(context.Background())
+	go apiCfg.runSweeper(context.Background())
+	go apiCfg.runPreviewer(context.Background())
+
 	mux := http.NewServeMux()
 	mux.Handle("/app/", apiCfg.middlewareMetricsInc(http.StripPrefix("/app", http.FileServer(http.Dir(filepathRoot)))))
 
 	mux.HandleFunc("GET /admin/metrics", apiCfg.handleMetrics)
 	mux.HandleFunc("POST /admin/reset", apiCfg.handleReset)
+	mux.HandleFunc("GET /admin/moderation/words", apiCfg.get_banned_words)
+	mux.HandleFunc("POST /admin/moderation/words", apiCfg.add_banned_word)
+	mux.HandleFunc("DELETE /admin/moderation/words/{word}", apiCfg.delete_banned_word)
+	mux.HandleFunc("PUT /admin/users/{userID}/suspension", apiCfg.suspend_user)
+	mux.HandleFunc("DELETE /admin/users/{userID}/suspension", apiCfg.unsuspend_user)
+	mux.HandleFunc("PUT /admin/users/{userID}/ban", apiCfg.ban_user)
+	mux.HandleFunc("DELETE /admin/users/{userID}/ban", apiCfg.unban_user)
+	mux.HandleFunc("GET /admin/held_chirps", apiCfg.get_held_chirps)
+	mux.HandleFunc("POST /admin/held_chirps/{chirpID}/review", apiCfg.review_held_chirp)
+	mux.HandleFunc("PUT /admin/chirps/{chirpID}/sensitivity", apiCfg.set_chirp_sensitivity)
+	mux.HandleFunc("GET /admin/reports", apiCfg.get_reports)
+	mux.HandleFunc("GET /admin/reports/{reportID}", apiCfg.get_report)
+	mux.HandleFunc("POST /admin/reports/{reportID}/assign", apiCfg.assign_report)
+	mux.HandleFunc("POST /admin/reports/{reportID}/resolve", apiCfg.resolve_report)
 
 	mux.HandleFunc("GET /api/healthz", func(w http.ResponseWriter, req *http.Request) {
 		w.Header().Set("Content-Type", "text/plain; charset=utf-8") // normal header
@@ -70,7 +168,50 @@ func main() {
 	mux.HandleFunc("GET /api/chirps", apiCfg.get_chirps)
 	mux.HandleFunc("GET /api/chirps/{chirpID}", apiCfg.get_chirp)
 	mux.HandleFunc("POST /api/chirps", apiCfg.create_chirp)
+	mux.HandleFunc("PATCH /api/chirps/{chirpID}", apiCfg.edit_chirp)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}", apiCfg.delete_chirp)
+	mux.HandleFunc("GET /api/chirps/{chirpID}/history", apiCfg.get_chirp_history)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/restore", apiCfg.restore_chirp)
+	mux.HandleFunc("GET /api/trash", apiCfg.get_trash)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/rechirp", apiCfg.rechirp)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/rechirp", apiCfg.undo_rechirp)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/vote", apiCfg.vote_poll)
+	mux.HandleFunc("PUT /api/chirps/{chirpID}/pin", apiCfg.pin_chirp)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/pin", apiCfg.unpin_chirp)
+	mux.HandleFunc("PUT /api/chirps/{chirpID}/bookmark", apiCfg.bookmark_chirp)
+	mux.HandleFunc("DELETE /api/chirps/{chirpID}/bookmark", apiCfg.unbookmark_chirp)
+	mux.HandleFunc("POST /api/chirps/{chirpID}/report", apiCfg.report_chirp)
+	mux.HandleFunc("GET /api/scheduled", apiCfg.get_scheduled_chirps)
+	mux.HandleFunc("PATCH /api/scheduled/{chirpID}", apiCfg.edit_scheduled_chirp)
+	mux.HandleFunc("DELETE /api/scheduled/{chirpID}", apiCfg.cancel_scheduled_chirp)
+	mux.HandleFunc("GET /api/drafts", apiCfg.get_drafts)
+	mux.HandleFunc("POST /api/drafts", apiCfg.create_draft)
+	mux.HandleFunc("GET /api/drafts/{draftID}", apiCfg.get_draft)
+	mux.HandleFunc("PUT /api/drafts/{draftID}", apiCfg.update_draft)
+	mux.HandleFunc("DELETE /api/drafts/{draftID}", apiCfg.delete_draft)
+	mux.HandleFunc("POST /api/drafts/{draftID}/publish", apiCfg.publish_draft)
+	mux.HandleFunc("POST /api/media", apiCfg.upload_media)
+	mux.HandleFunc("GET /api/media/{mediaID}", apiCfg.get_media)
+	mux.HandleFunc("GET /avatars/{file}", apiCfg.get_avatar)
+	mux.HandleFunc("GET /l/{code}", apiCfg.follow_link)
+	mux.HandleFunc("GET /api/bookmarks", apiCfg.get_bookmarks)
+	mux.HandleFunc("GET /api/hashtags/{tag}/chirps", apiCfg.get_hashtag_chirps)
+	mux.HandleFunc("GET /api/trending", apiCfg.get_trending)
+	mux.HandleFunc("GET /api/search", apiCfg.search_all)
+	mux.HandleFunc("GET /api/mentions", apiCfg.get_mentions)
+	mux.HandleFunc("GET /api/notifications", apiCfg.get_notifications)
+	mux.HandleFunc("POST /api/notifications/read", apiCfg.read_notifications)
+	mux.HandleFunc("GET /api/notifications/unread_count", apiCfg.get_unread_count)
 	mux.HandleFunc("POST /api/users", apiCfg.create_user)
+	mux.HandleFunc("PUT /api/users/me", apiCfg.update_user)
+	mux.HandleFunc("DELETE /api/users/me", apiCfg.delete_user)
+	mux.HandleFunc("PUT /api/users/me/avatar", apiCfg.update_avatar)
+	mux.HandleFunc("GET /api/users/me/preferences", apiCfg.get_preferences)
+	mux.HandleFunc("PUT /api/users/me/preferences", apiCfg.update_preferences)
+	mux.HandleFunc("POST /api/users/restore", apiCfg.restore_user)
+	mux.HandleFunc("POST /api/users/{userID}/report", apiCfg.report_user)
+	mux.HandleFunc("PUT /api/users/{userID}/follow", apiCfg.follow_user)
+	mux.HandleFunc("DELETE /api/users/{userID}/follow", apiCfg.unfollow_user)
 	mux.HandleFunc("POST /api/login", apiCfg.login_user)
 	mux.HandleFunc("POST /api/refresh", apiCfg.refresh_token)
 	mux.HandleFunc("POST /api/revoke", apiCfg.revoke_refresh)
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"chirpy/internal/database"
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NotificationKind identifies the event a notification describes. Kinds are
+// stored as text, so adding one only needs a new constant here.
+type NotificationKind string
+
+const (
+	NotificationMention             NotificationKind = "mention"
+	NotificationNewFollower         NotificationKind = "follow"
+	NotificationNewLogin            NotificationKind = "security.new_login"
+	NotificationRefreshTokenRevoked NotificationKind = "security.refresh_token_revoked"
+	NotificationChirpModerated      NotificationKind = "moderation.chirp"
+	NotificationAccountSuspended    NotificationKind = "moderation.account_suspended"
+)
+
+// Notification is an event to deliver to a user's inbox. Data is marshalled
+// to JSON and should describe the event for display.
+type Notification struct {
+	UserID  uuid.UUID
+	Kind    NotificationKind
+	ActorID uuid.NullUUID
+	ChirpID uuid.NullUUID
+	Data    any
+}
+
+// notify adds n to its user's inbox. Pass a transaction's queries to make
+// the notification part of it, or cfg.db otherwise.
+func notify(ctx context.Context, q *database.Queries, n Notification) error {
+	data := json.RawMessage("{}")
+	if n.Data != nil {
+		var err error
+		data, err = json.Marshal(n.Data)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := q.CreateNotification(ctx, database.CreateNotificationParams{
+		UserID:  n.UserID,
+		Kind:    string(n.Kind),
+		ActorID: n.ActorID,
+		ChirpID: n.ChirpID,
+		Data:    data,
+	})
+	return err
+}
+
+func (cfg *apiConfig) get_notifications(w http.ResponseWriter, req *http.Request) {
+	userID, ok := cfg.authenticate(w, req)
+	if !ok {
+		return
+	}
+
+	type notificationS struct {
+		ID        uuid.UUID        `json:"id"`
+		Kind      NotificationKind `json:"kind"`
+		ActorID   *uuid.UUID       `json:"actor_id"`
+		ChirpID   *uuid.UUID       `json:"chirp_id"`
+		Data      json.RawMessage  `json:"data"`
+		ReadAt    *time.Time       `json:"read_at"`
+		CreatedAt time.Time        `json:"created_at"`
+	}
+
+	p, err := parsePage(req)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	notifications, err := cfg.db.GetNotifications(req.Context(), database.GetNotificationsParams{
+		UserID:     userID,
+		Before:     p.Before,
+		BeforeID:   p.BeforeID,
+		UnreadOnly: req.URL.Query().Get("unread") == "true",
+		PageSize:   p.Limit,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve notifications", err)
+		return
+	}
+
+	response := make([]notificationS, len(notifications))
+	for i, n := range notifications {
+		response[i] = notificationS{
+			ID:        n.ID,
+			Kind:      NotificationKind(n.Kind),
+			Data:      n.Data,
+			CreatedAt: n.CreatedAt,
+		}
+		if n.ActorID.Valid {
+			response[i].ActorID = &n.ActorID.UUID
+		}
+		if n.ChirpID.Valid {
+			response[i].ChirpID = &n.ChirpID.UUID
+		}
+		if n.ReadAt.Valid {
+			response[i].ReadAt = &n.ReadAt.Time
+		}
+	}
+	responseWithJSON(w, http.StatusOK, response)
+}
+
+func (cfg *apiConfig) read_notifications(w http.ResponseWriter, req *http.Request) {
+	userID, ok := cfg.authenticate(w, req)
+	if !ok {
+		return
+	}
+
+	type parameters struct {
+		IDs []uuid.UUID `json:"ids"`
+		All bool        `json:"all"`
+	}
+
+	type successS struct {
+		Updated int64 `json:"updated"`
+	}
+
+	decoder := json.NewDecoder(req.Body)
+	params := parameters{}
+	err := decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	if !params.All && len(params.IDs) == 0 {
+		respondWithError(w, http.StatusBadRequest, "Provide notification ids or set all", nil)
+		return
+	}
+
+	updated, err := cfg.db.MarkNotificationsRead(req.Context(), database.MarkNotificationsReadParams{
+		UserID:  userID,
+		MarkAll: params.All,
+		Ids:     params.IDs,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not mark notifications read", err)
+		return
+	}
+
+	responseWithJSON(w, http.StatusOK, successS{Updated: updated})
+}
+
+func (cfg *apiConfig) get_unread_count(w http.ResponseWriter, req *http.Request) {
+	userID, ok := cfg.authenticate(w, req)
+	if !ok {
+		return
+	}
+
+	type successS struct {
+		Count int64 `json:"count"`
+	}
+
+	count, err := cfg.db.CountUnreadNotifications(req.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not count notifications", err)
+		return
+	}
+
+	responseWithJSON(w, http.StatusOK, successS{Count: count})
+}
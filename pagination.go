@@ -0,0 +1,104 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+	// maxPageOffset bounds how deep offset pagination goes, since the
+	// database still has to produce every skipped row.
+	maxPageOffset = 1000
+)
+
+// endOfTime is the cursor used for the first page of a listing.
+var endOfTime = time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+
+// page is a keyset pagination cursor: the listing returns up to Limit rows
+// that sort strictly before (Before, BeforeID), newest first, with the ID
+// breaking ties between rows created at the same moment. Clients request the
+// next page by passing the timestamp and ID of the last row they received as
+// ?before= and ?before_id=. Without ?before_id=, every row at Before is
+// skipped, as it was before the ID was part of the cursor.
+type page struct {
+	Before   time.Time
+	BeforeID uuid.UUID
+	Limit    int32
+}
+
+// parsePage reads the optional ?limit=, ?before= (RFC 3339) and ?before_id=
+// query parameters.
+func parsePage(req *http.Request) (page, error) {
+	limit, err := parseLimit(req)
+	if err != nil {
+		return page{}, err
+	}
+	p := page{Before: endOfTime, Limit: limit}
+
+	query := req.URL.Query()
+	if before := query.Get("before"); before != "" {
+		t, err := time.Parse(time.RFC3339Nano, before)
+		if err != nil {
+			return page{}, errors.New("before must be an RFC 3339 timestamp")
+		}
+		p.Before = t
+	}
+
+	if beforeID := query.Get("before_id"); beforeID != "" {
+		if !query.Has("before") {
+			return page{}, errors.New("before_id requires before")
+		}
+		id, err := uuid.Parse(beforeID)
+		if err != nil {
+			return page{}, errors.New("before_id must be a UUID")
+		}
+		p.BeforeID = id
+	}
+
+	return p, nil
+}
+
+// offsetPage is an offset pagination cursor, for listings ordered by
+// something other than time, such as relevance, where a keyset cursor does
+// not work. Clients request the next page by adding Limit to ?offset=.
+type offsetPage struct {
+	Limit  int32
+	Offset int32
+}
+
+// parseOffsetPage reads the optional ?limit= and ?offset= query parameters.
+func parseOffsetPage(req *http.Request) (offsetPage, error) {
+	limit, err := parseLimit(req)
+	if err != nil {
+		return offsetPage{}, err
+	}
+	p := offsetPage{Limit: limit}
+
+	if offset := req.URL.Query().Get("offset"); offset != "" {
+		n, err := strconv.Atoi(offset)
+		if err != nil || n < 0 || n > maxPageOffset {
+			return offsetPage{}, errors.New("offset must be between 0 and 1000")
+		}
+		p.Offset = int32(n)
+	}
+
+	return p, nil
+}
+
+func parseLimit(req *http.Request) (int32, error) {
+	limit := req.URL.Query().Get("limit")
+	if limit == "" {
+		return defaultPageSize, nil
+	}
+	n, err := strconv.Atoi(limit)
+	if err != nil || n < 1 || n > maxPageSize {
+		return 0, errors.New("limit must be between 1 and 100")
+	}
+	return int32(n), nil
+}
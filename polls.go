@@ -0,0 +1,290 @@
+package main
+
+import (
+	"chirpy/internal/chirptext"
+	"chirpy/internal/database"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	minPollOptions      = 2
+	maxPollOptions      = 4
+	maxPollOptionLength = 50
+	minPollDuration     = 5 * time.Minute
+	maxPollDuration     = 7 * 24 * time.Hour
+)
+
+// When voters can see a poll's results. The author can always see them, and
+// everyone can once the poll has closed.
+const (
+	pollResultsAfterVote  = "after_vote"
+	pollResultsAfterClose = "after_close"
+)
+
+// pollParameters is the poll attached to a new chirp.
+type pollParameters struct {
+	Options           []string  `json:"options"`
+	ClosesAt          time.Time `json:"closes_at"`
+	AllowVoteChange   bool      `json:"allow_vote_change"`
+	ResultsVisibility string    `json:"results_visibility"`
+}
+
+// preparedPoll is a validated poll, written with createPoll once its chirp
+// exists.
+type preparedPoll struct {
+	closesAt          time.Time
+	allowVoteChange   bool
+	resultsVisibility string
+	options           []string
+}
+
+// preparePoll validates and censors a poll for a chirp that is published at
+// opensAt.
+func (cfg *apiConfig) preparePoll(params pollParameters, opensAt time.Time) (preparedPoll, error) {
+	if len(params.Options) < minPollOptions || len(params.Options) > maxPollOptions {
+		return preparedPoll{}, fmt.Errorf("polls must have %d to %d options", minPollOptions, maxPollOptions)
+	}
+
+	poll := preparedPoll{
+		closesAt:          params.ClosesAt.UTC(),
+		allowVoteChange:   params.AllowVoteChange,
+		resultsVisibility: pollResultsAfterVote,
+	}
+	switch params.ResultsVisibility {
+	case "":
+	case pollResultsAfterVote, pollResultsAfterClose:
+		poll.resultsVisibility = params.ResultsVisibility
+	default:
+		return preparedPoll{}, errors.New("results_visibility must be after_vote or after_close")
+	}
+
+	duration := poll.closesAt.Sub(opensAt)
+	if duration < minPollDuration || duration > maxPollDuration {
+		return preparedPoll{}, fmt.Errorf("polls must close between %s and %s after they are published", minPollDuration, maxPollDuration)
+	}
+
+	seen := make(map[string]bool, len(params.Options))
+	for _, option := range params.Options {
+		text, err := chirptext.Normalize(option)
+		if errors.Is(err, chirptext.ErrEmpty) {
+			return preparedPoll{}, errors.New("poll options must not be empty")
+		}
+		if err != nil {
+			return preparedPoll{}, err
+		}
+		if chirptext.Length(text) > maxPollOptionLength {
+			return preparedPoll{}, fmt.Errorf("poll options must be at most %d characters", maxPollOptionLength)
+		}
+		if seen[text] {
+			return preparedPoll{}, errors.New("poll options must be different")
+		}
+		seen[text] = true
+		poll.options = append(poll.options, cfg.filter.Censor(text))
+	}
+	return poll, nil
+}
+
+// createPoll writes a prepared poll for chirpID. It should run in the same
+// transaction as the chirp.
+func createPoll(ctx context.Context, q *database.Queries, chirpID uuid.UUID, poll preparedPoll) error {
+	err := q.CreatePoll(ctx, database.CreatePollParams{
+		ChirpID:           chirpID,
+		ClosesAt:          poll.closesAt,
+		AllowVoteChange:   poll.allowVoteChange,
+		ResultsVisibility: poll.resultsVisibility,
+	})
+	if err != nil {
+		return err
+	}
+	for i, option := range poll.options {
+		err := q.CreatePollOption(ctx, database.CreatePollOptionParams{
+			ChirpID:  chirpID,
+			Position: int32(i),
+			Text:     option,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Poll is the JSON representation of a poll, embedded in its chirp. Vote
+// counts are left out while the results are hidden from the caller.
+type Poll struct {
+	Options           []PollOption `json:"options"`
+	ClosesAt          time.Time    `json:"closes_at"`
+	Closed            bool         `json:"closed"`
+	AllowVoteChange   bool         `json:"allow_vote_change"`
+	ResultsVisibility string       `json:"results_visibility"`
+	VotedOptionID     *uuid.UUID   `json:"voted_option_id"`
+	TotalVotes        *int64       `json:"total_votes,omitempty"`
+}
+
+type PollOption struct {
+	ID    uuid.UUID `json:"id"`
+	Text  string    `json:"text"`
+	Votes *int64    `json:"votes,omitempty"`
+}
+
+// chirpPolls loads the polls of the given chirps, keyed by chirp ID, as seen
+// by viewerID. authors maps each chirp ID to its author.
+func (cfg *apiConfig) chirpPolls(ctx context.Context, authors map[uuid.UUID]uuid.UUID, viewerID uuid.UUID) (map[uuid.UUID]*Poll, error) {
+	chirpIDs := make([]uuid.UUID, 0, len(authors))
+	for id := range authors {
+		chirpIDs = append(chirpIDs, id)
+	}
+
+	rows, err := cfg.db.GetPolls(ctx, chirpIDs)
+	if err != nil {
+		return nil, err
+	}
+	polls := make(map[uuid.UUID]*Poll, len(rows))
+	if len(rows) == 0 {
+		return polls, nil
+	}
+
+	pollIDs := make([]uuid.UUID, len(rows))
+	for i, row := range rows {
+		pollIDs[i] = row.ChirpID
+	}
+	options, err := cfg.db.GetPollOptions(ctx, pollIDs)
+	if err != nil {
+		return nil, err
+	}
+	votes, err := cfg.db.GetPollVotes(ctx, database.GetPollVotesParams{UserID: viewerID, ChirpIds: pollIDs})
+	if err != nil {
+		return nil, err
+	}
+	voted := make(map[uuid.UUID]uuid.UUID, len(votes))
+	for _, vote := range votes {
+		voted[vote.ChirpID] = vote.OptionID
+	}
+
+	now := time.Now()
+	showResults := make(map[uuid.UUID]bool, len(rows))
+	for _, row := range rows {
+		poll := &Poll{
+			Options:           []PollOption{},
+			ClosesAt:          row.ClosesAt,
+			Closed:            !now.Before(row.ClosesAt),
+			AllowVoteChange:   row.AllowVoteChange,
+			ResultsVisibility: row.ResultsVisibility,
+		}
+		optionID, hasVoted := voted[row.ChirpID]
+		if hasVoted {
+			poll.VotedOptionID = &optionID
+		}
+		showResults[row.ChirpID] = poll.Closed ||
+			authors[row.ChirpID] == viewerID ||
+			(row.ResultsVisibility == pollResultsAfterVote && hasVoted)
+		if showResults[row.ChirpID] {
+			poll.TotalVotes = new(int64)
+		}
+		polls[row.ChirpID] = poll
+	}
+
+	for _, option := range options {
+		poll := polls[option.ChirpID]
+		response := PollOption{ID: option.ID, Text: option.Text}
+		if showResults[option.ChirpID] {
+			votes := option.Votes
+			response.Votes = &votes
+			*poll.TotalVotes += votes
+		}
+		poll.Options = append(poll.Options, response)
+	}
+	return polls, nil
+}
+
+// vote_poll casts the caller's vote in the poll of a chirp. Whether a vote
+// can be changed afterwards is up to the poll.
+func (cfg *apiConfig) vote_poll(w http.ResponseWriter, req *http.Request) {
+	userID, ok := cfg.authenticate(w, req)
+	if !ok {
+		return
+	}
+
+	type parameters struct {
+		OptionID uuid.UUID `json:"option_id"`
+	}
+
+	id, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Bad ID", err)
+		return
+	}
+
+	decoder := json.NewDecoder(req.Body)
+	params := parameters{}
+	err = decoder.Decode(&params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't decode parameters", err)
+		return
+	}
+
+	chirp, err := cfg.db.GetChirp(req.Context(), database.GetChirpParams{ID: id, ViewerID: userID})
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Not found", err)
+		return
+	}
+
+	poll, err := cfg.db.GetPoll(req.Context(), chirp.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Chirp has no poll", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve poll", err)
+		return
+	}
+	if !time.Now().Before(poll.ClosesAt) {
+		respondWithError(w, http.StatusConflict, "Poll is closed", nil)
+		return
+	}
+
+	options, err := cfg.db.GetPollOptions(req.Context(), []uuid.UUID{chirp.ID})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve poll", err)
+		return
+	}
+	known := false
+	for _, option := range options {
+		known = known || option.ID == params.OptionID
+	}
+	if !known {
+		respondWithError(w, http.StatusBadRequest, "Unknown option", nil)
+		return
+	}
+
+	vote := database.CastPollVoteParams{ChirpID: chirp.ID, UserID: userID, OptionID: params.OptionID}
+	if poll.AllowVoteChange {
+		err = cfg.db.ChangePollVote(req.Context(), database.ChangePollVoteParams(vote))
+	} else {
+		var cast int64
+		cast, err = cfg.db.CastPollVote(req.Context(), vote)
+		if err == nil && cast == 0 {
+			respondWithError(w, http.StatusConflict, "Already voted", nil)
+			return
+		}
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not vote", err)
+		return
+	}
+
+	response, err := cfg.buildChirp(req.Context(), chirp, userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not build chirp", err)
+		return
+	}
+	responseWithJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"chirpy/internal/database"
+	"chirpy/internal/preview"
+	"context"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	previewInterval  = time.Minute
+	previewBatchSize = 20
+	// previewMaxAge is how long a preview is cached before the page is
+	// fetched again. Failed fetches are cached as empty previews, so a dead
+	// link is not retried on every run either.
+	previewMaxAge = 7 * 24 * time.Hour
+)
+
+var previewLimits = preview.Limits{
+	Timeout:      5 * time.Second,
+	MaxRedirects: 3,
+	MaxBytes:     512 << 10,
+}
+
+// LinkPreview is the JSON representation of the card for a link.
+type LinkPreview struct {
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	ImageURL    string `json:"image_url,omitempty"`
+}
+
+// refreshPreviews fetches previews for links that have none yet or whose
+// preview is stale, one batch at a time. Fetching happens here rather than
+// when a chirp is posted so that a slow or hostile site never holds up a
+// request.
+func (cfg *apiConfig) refreshPreviews(ctx context.Context) error {
+	for {
+		links, err := cfg.db.GetLinksToPreview(ctx, database.GetLinksToPreviewParams{
+			StaleBefore: time.Now().UTC().Add(-previewMaxAge),
+			BatchSize:   previewBatchSize,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, link := range links {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			card, err := cfg.previews.Fetch(ctx, link.Url)
+			if err != nil {
+				log.Printf("Could not preview link %s: %s", link.Code, err)
+			}
+			err = cfg.saveLinkPreview(ctx, link.ID, card)
+			if err != nil {
+				// Store an empty card instead, so one page the database
+				// refuses does not come first in every batch and stall the
+				// previews of every link behind it.
+				log.Printf("Could not save preview of link %s: %s", link.Code, err)
+				err = cfg.saveLinkPreview(ctx, link.ID, preview.Preview{})
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(links) < previewBatchSize {
+			return nil
+		}
+	}
+}
+
+func (cfg *apiConfig) saveLinkPreview(ctx context.Context, linkID uuid.UUID, card preview.Preview) error {
+	return cfg.db.SaveLinkPreview(ctx, database.SaveLinkPreviewParams{
+		LinkID:      linkID,
+		Title:       card.Title,
+		Description: card.Description,
+		ImageUrl:    card.ImageURL,
+	})
+}
+
+// runPreviewer refreshes link previews every previewInterval until ctx is
+// cancelled.
+func (cfg *apiConfig) runPreviewer(ctx context.Context) {
+	ticker := time.NewTicker(previewInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := cfg.refreshPreviews(ctx); err != nil {
+			log.Printf("Could not refresh link previews: %s", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"chirpy/internal/database"
+	"context"
+	"log"
+	"time"
+)
+
+const (
+	purgeInterval  = time.Hour
+	purgeBatchSize = 500
+	// mediaOrphanAge is how long an upload may go without being attached to
+	// a chirp before it is deleted.
+	mediaOrphanAge = 24 * time.Hour
+)
+
+// purgeDeleted permanently deletes chirps and users that have been in the
+// trash for longer than trashRetention. A deleted user's chirps are purged
+// before the user, so the final cascade stays small.
+func (cfg *apiConfig) purgeDeleted(ctx context.Context) error {
+	cutoff := trashCutoff()
+
+	err := purgeInBatches(ctx, "chirps", func() (int64, error) {
+		return cfg.db.PurgeDeletedChirps(ctx, database.PurgeDeletedChirpsParams{Cutoff: cutoff, BatchSize: purgeBatchSize})
+	})
+	if err != nil {
+		return err
+	}
+
+	err = purgeInBatches(ctx, "chirps of deleted users", func() (int64, error) {
+		return cfg.db.PurgeChirpsOfDeletedUsers(ctx, database.PurgeChirpsOfDeletedUsersParams{Cutoff: cutoff, BatchSize: purgeBatchSize})
+	})
+	if err != nil {
+		return err
+	}
+
+	err = purgeInBatches(ctx, "users", func() (int64, error) {
+		return cfg.db.PurgeDeletedUsers(ctx, database.PurgeDeletedUsersParams{Cutoff: cutoff, BatchSize: purgeBatchSize})
+	})
+	if err != nil {
+		return err
+	}
+
+	return cfg.purgeOrphanedMedia(ctx)
+}
+
+// purgeOrphanedMedia deletes uploads that no chirp is attached to once they
+// are older than mediaOrphanAge, which covers the images of purged and
+// expired chirps as well as uploads that were never attached. A stored file
+// is deleted along with the last upload that uses it.
+func (cfg *apiConfig) purgeOrphanedMedia(ctx context.Context) error {
+	cutoff := time.Now().UTC().Add(-mediaOrphanAge)
+	return purgeInBatches(ctx, "orphaned media files", func() (int64, error) {
+		keys, err := cfg.db.DeleteOrphanedMediaFiles(ctx, database.DeleteOrphanedMediaFilesParams{Cutoff: cutoff, BatchSize: purgeBatchSize})
+		if err != nil {
+			return 0, err
+		}
+		for _, key := range keys {
+			if err := cfg.deleteStoredMedia(ctx, key); err != nil {
+				log.Printf("Could not delete media file %s: %s", key, err)
+			}
+		}
+		return int64(len(keys)), nil
+	})
+}
+
+func (cfg *apiConfig) deleteStoredMedia(ctx context.Context, key string) error {
+	inUse, err := cfg.db.MediaKeyInUse(ctx, key)
+	if err != nil || inUse {
+		return err
+	}
+	return cfg.storage.Delete(ctx, key)
+}
+
+// purgeInBatches calls purge until it deletes fewer than purgeBatchSize rows.
+// Each batch is its own statement, so no lock is held for long, and
+// concurrent purgers skip each other's rows instead of waiting on them.
+func purgeInBatches(ctx context.Context, name string, purge func() (int64, error)) error {
+	var total int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		n, err := purge()
+		if err != nil {
+			return err
+		}
+		total += n
+		if n < purgeBatchSize {
+			break
+		}
+	}
+
+	if total > 0 {
+		log.Printf("Purged %d %s", total, name)
+	}
+	return nil
+}
+
+// runPurge purges expired trash every purgeInterval until ctx is cancelled.
+func (cfg *apiConfig) runPurge(ctx context.Context) {
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := cfg.purgeDeleted(ctx); err != nil {
+			log.Printf("Could not purge deleted items: %s", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"chirpy/internal/database"
+	"chirpy/internal/moderation"
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	publishInterval  = 30 * time.Second
+	publishBatchSize = 100
+)
+
+// publishDue publishes scheduled chirps whose time has come, one batch per
+// transaction. Rows are claimed with FOR UPDATE SKIP LOCKED and indexed in
+// the same transaction, so with several replicas running each chirp is
+// published, and its mentions notified, exactly once. Scheduled chirps live
+// in the database, so nothing is lost across restarts.
+func (cfg *apiConfig) publishDue(ctx context.Context) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		n, err := cfg.publishBatch(ctx)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			log.Printf("Published %d scheduled chirps", n)
+		}
+		if n < publishBatchSize {
+			return nil
+		}
+	}
+}
+
+func (cfg *apiConfig) publishBatch(ctx context.Context) (int, error) {
+	tx, err := cfg.dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	chirps, err := qtx.PublishDueChirps(ctx, publishBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	for _, chirp := range chirps {
+		if err := saveChirpEntities(ctx, qtx, chirp); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(chirps), tx.Commit()
+}
+
+// runPublisher publishes due chirps every publishInterval until ctx is
+// cancelled.
+func (cfg *apiConfig) runPublisher(ctx context.Context) {
+	ticker := time.NewTicker(publishInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := cfg.publishDue(ctx); err != nil {
+			log.Printf("Could not publish scheduled chirps: %s", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (cfg *apiConfig) get_scheduled_chirps(w http.ResponseWriter, req *http.Request) {
+	userID, ok := cfg.authenticate(w, req)
+	if !ok {
+		return
+	}
+
+	chirps, err := cfg.db.GetScheduledChirps(req.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve chirps", err)
+		return
+	}
+
+	responseChirps, err := cfg.buildChirps(req.Context(), chirps, userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve chirps", err)
+		return
+	}
+	responseWithJSON(w, http.StatusOK, responseChirps)
+}
+
+// edit_scheduled_chirp changes the body or publication time of a chirp that
+// has not been published yet. Omitted fields are left as they are. A new body
+// is screened like a new chirp. An ephemeral chirp keeps its lifetime and a
+// poll its duration when the chirp is moved.
+func (cfg *apiConfig) edit_scheduled_chirp(w http.ResponseWriter, req *http.Request) {
+	userID, ok := cfg.authenticate(w, req)
+	if !ok {
+		return
+	}
+
+	type parameters struct {
+		Body      *string    `json:"body"`
+		PublishAt *time.Time `json:"publish_at"`
+	}
+
+	id, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Bad ID", err)
+		return
+	}
+
+	params := parameters{}
+	if !decodeChirpRequest(w, req, &params) {
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(req.Context(), nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not edit chirp", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	// The chirp stays locked until the edit is written, so concurrent edits
+	// cannot move its poll and expiry by the same shift twice.
+	chirp, err := qtx.GetScheduledChirp(req.Context(), database.GetScheduledChirpParams{ID: id, UserID: userID})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "No scheduled chirp with that ID", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve chirp", err)
+		return
+	}
+
+	body := chirp.Body
+	held := false
+	var results []moderation.Result
+	if params.Body != nil {
+		body, err = cfg.validateChirp(req.Context(), userID, *params.Body)
+		if err != nil {
+			respondWithChirpError(w, err)
+			return
+		}
+		held, results, err = cfg.screenChirp(req.Context(), userID, uuid.NullUUID{UUID: chirp.ID, Valid: true}, body)
+		if err != nil {
+			respondWithChirpError(w, err)
+			return
+		}
+	}
+
+	publishAt := chirp.ScheduledFor.Time
+	if params.PublishAt != nil {
+		if !params.PublishAt.After(time.Now()) {
+			respondWithError(w, http.StatusBadRequest, "publish_at must be in the future", nil)
+			return
+		}
+		publishAt = params.PublishAt.UTC()
+	}
+	shift := publishAt.Sub(chirp.ScheduledFor.Time)
+	expiresAt := chirp.ExpiresAt
+	if expiresAt.Valid {
+		expiresAt.Time = expiresAt.Time.Add(shift)
+	}
+
+	edited, err := qtx.UpdateScheduledChirp(req.Context(), database.UpdateScheduledChirpParams{
+		Body:         body,
+		ScheduledFor: sql.NullTime{Time: publishAt, Valid: true},
+		ExpiresAt:    expiresAt,
+		HeldAt:       sql.NullTime{Time: time.Now().UTC(), Valid: held},
+		ID:           chirp.ID,
+		UserID:       userID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusConflict, "Chirp has already been published", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not edit chirp", err)
+		return
+	}
+
+	if shift != 0 {
+		err := qtx.ShiftPollClose(req.Context(), database.ShiftPollCloseParams{ShiftSeconds: shift.Seconds(), ChirpID: edited.ID})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Could not move poll", err)
+			return
+		}
+	}
+
+	if held {
+		err := saveAutomodResults(req.Context(), qtx, userID, uuid.NullUUID{UUID: edited.ID, Valid: true}, edited.Body, results)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Could not edit chirp", err)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not edit chirp", err)
+		return
+	}
+
+	response, err := cfg.buildChirp(req.Context(), edited, userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not build chirp", err)
+		return
+	}
+	responseWithJSON(w, http.StatusOK, response)
+}
+
+// cancel_scheduled_chirp deletes a chirp that has not been published yet.
+func (cfg *apiConfig) cancel_scheduled_chirp(w http.ResponseWriter, req *http.Request) {
+	userID, ok := cfg.authenticate(w, req)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Bad ID", err)
+		return
+	}
+
+	deleted, err := cfg.db.DeleteScheduledChirp(req.Context(), database.DeleteScheduledChirpParams{ID: id, UserID: userID})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not cancel chirp", err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusNotFound, "No scheduled chirp with that ID", nil)
+		return
+	}
+
+	responseWithJSON(w, http.StatusNoContent, nil)
+}
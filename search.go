@@ -0,0 +1,124 @@
+package main
+
+import (
+	"chirpy/internal/database"
+	"chirpy/internal/render"
+	"chirpy/internal/search"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// searchRecencyHalfLife controls the recency boost in search ranking: a
+// chirp posted now ranks up to twice as high as an equally relevant old one,
+// and the boost halves every searchRecencyHalfLife.
+const searchRecencyHalfLife = 7 * 24 * time.Hour
+
+// search_all finds chirps and users matching ?q=. Chirps are filtered the
+// way listings are, so the caller only finds chirps they could read
+// elsewhere; users are matched on their handle. See search.Parse for the
+// query syntax.
+func (cfg *apiConfig) search_all(w http.ResponseWriter, req *http.Request) {
+	type chirpResult struct {
+		Chirp       Chirp  `json:"chirp"`
+		SnippetHTML string `json:"snippet_html"`
+	}
+
+	type userResult struct {
+		ID         uuid.UUID         `json:"id"`
+		Handle     string            `json:"handle"`
+		AvatarURLs map[string]string `json:"avatar_urls,omitempty"`
+	}
+
+	type successS struct {
+		Chirps []chirpResult `json:"chirps"`
+		Users  []userResult  `json:"users"`
+	}
+
+	viewerID, ok := cfg.viewer(w, req)
+	if !ok {
+		return
+	}
+
+	query, err := search.Parse(req.URL.Query().Get("q"))
+	if errors.Is(err, search.ErrEmpty) {
+		respondWithError(w, http.StatusBadRequest, "q is required", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	p, err := parseOffsetPage(req)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	hide, err := hideSensitive(req)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	rows, err := cfg.db.SearchChirps(req.Context(), database.SearchChirpsParams{
+		Query:           query.TSQuery,
+		FromHandle:      query.From,
+		Tags:            query.Tags,
+		ViewerID:        viewerID,
+		HideSensitive:   hide,
+		HalfLifeSeconds: searchRecencyHalfLife.Seconds(),
+		PageSize:        p.Limit,
+		PageOffset:      p.Offset,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not search chirps", err)
+		return
+	}
+
+	chirps := make([]database.Chirp, len(rows))
+	for i, row := range rows {
+		chirps[i] = row.Chirp
+	}
+	responseChirps, err := cfg.buildChirps(req.Context(), chirps, viewerID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not search chirps", err)
+		return
+	}
+
+	response := successS{
+		Chirps: make([]chirpResult, len(rows)),
+		Users:  []userResult{},
+	}
+	for i, row := range rows {
+		response.Chirps[i] = chirpResult{
+			Chirp:       responseChirps[i],
+			SnippetHTML: render.Highlight(row.Snippet),
+		}
+	}
+
+	// Operators only narrow down chirps; users are found by text alone.
+	if query.TSQuery != "" && query.From == "" && len(query.Tags) == 0 {
+		users, err := cfg.db.SearchUsers(req.Context(), database.SearchUsersParams{
+			Query:      query.TSQuery,
+			PageSize:   p.Limit,
+			PageOffset: p.Offset,
+		})
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Could not search users", err)
+			return
+		}
+		for _, user := range users {
+			response.Users = append(response.Users, userResult{
+				ID:         user.ID,
+				Handle:     user.Handle.String,
+				AvatarURLs: avatarURLs(user.Avatar),
+			})
+		}
+	}
+
+	responseWithJSON(w, http.StatusOK, response)
+}
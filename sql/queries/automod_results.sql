@@ -0,0 +1,15 @@
+-- name: CreateAutomodResult :exec
+INSERT INTO automod_results (user_id, chirp_id, body, rule, verdict, reason)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6
+);
+
+-- name: GetAutomodResults :many
+SELECT * FROM automod_results
+WHERE chirp_id = ANY(@chirp_ids::uuid[])
+ORDER BY created_at ASC;
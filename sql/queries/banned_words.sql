@@ -0,0 +1,14 @@
+-- name: GetBannedWords :many
+SELECT word FROM banned_words ORDER BY word;
+
+-- name: AddBannedWord :exec
+INSERT INTO banned_words (word, created_by)
+VALUES (
+    $1,
+    $2
+)
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteBannedWord :execrows
+DELETE FROM banned_words
+WHERE word = $1;
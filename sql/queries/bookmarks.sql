@@ -0,0 +1,24 @@
+-- name: CreateBookmark :exec
+INSERT INTO bookmarks (user_id, chirp_id)
+VALUES (
+    $1,
+    $2
+)
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteBookmark :exec
+DELETE FROM bookmarks
+WHERE user_id = $1
+AND chirp_id = $2;
+
+-- name: GetBookmarkedChirps :many
+SELECT sqlc.embed(chirps), bookmarks.created_at AS bookmarked_at FROM chirps
+INNER JOIN bookmarks
+ON chirps.id = bookmarks.chirp_id
+WHERE bookmarks.user_id = @user_id
+AND (bookmarks.created_at, chirps.id) < (@before::timestamp, @before_id::uuid)
+AND chirp_live(chirps)
+AND chirp_readable(chirps, @user_id)
+AND NOT (@hide_sensitive::bool AND chirp_sensitive(chirps))
+ORDER BY bookmarks.created_at DESC, chirps.id DESC
+LIMIT @page_size;
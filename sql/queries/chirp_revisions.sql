@@ -0,0 +1,12 @@
+-- name: CreateChirpRevision :exec
+INSERT INTO chirp_revisions (chirp_id, body, written_at)
+VALUES (
+    $1,
+    $2,
+    $3
+);
+
+-- name: GetChirpRevisions :many
+SELECT * FROM chirp_revisions
+WHERE chirp_id = $1
+ORDER BY created_at DESC;
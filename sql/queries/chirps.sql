@@ -1,14 +1,252 @@
 -- name: CreateChirp :one
-INSERT INTO chirps (body, user_id)
+INSERT INTO chirps (body, user_id, quote_of, held_at, content_warning, sensitive, visibility, scheduled_for, expires_at)
 VALUES (
     $1,
-    $2
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9
+)
+RETURNING *;
+
+-- name: CreateRechirp :one
+INSERT INTO chirps (body, user_id, rechirp_of, visibility, expires_at)
+VALUES (
+    '',
+    $1,
+    $2,
+    $3,
+    $4
 )
 RETURNING *;
 
 -- name: GetChirps :many
-SELECT * FROM chirps ORDER BY created_at ASC;
+SELECT chirps.* FROM chirps
+WHERE chirp_live(chirps)
+AND chirps.visibility = 'public'
+AND NOT (@hide_sensitive::bool AND chirp_sensitive(chirps))
+ORDER BY chirps.created_at ASC;
 
 
 -- name: GetChirp :one
-SELECT * FROM chirps WHERE id = $1;
\ No newline at end of file
+SELECT chirps.* FROM chirps
+WHERE chirps.id = @id
+AND chirp_live(chirps)
+AND chirp_readable(chirps, @viewer_id);
+
+-- name: GetChirpForUpdate :one
+SELECT chirps.* FROM chirps
+WHERE chirps.id = @id
+AND chirp_live(chirps)
+AND chirp_readable(chirps, @viewer_id)
+FOR UPDATE;
+
+-- name: GetChirpsByIDs :many
+SELECT chirps.* FROM chirps
+WHERE chirps.id = ANY(@ids::uuid[])
+AND chirp_live(chirps)
+-- Only public and unlisted chirps can be rechirped or quoted.
+AND chirps.visibility IN ('public', 'unlisted');
+
+-- name: SoftDeleteChirp :execrows
+UPDATE chirps
+SET deleted_at = now(),
+    pinned_at = NULL
+WHERE id = $1
+AND user_id = $2
+AND deleted_at IS NULL;
+
+-- name: DeleteRechirp :execrows
+DELETE FROM chirps
+WHERE user_id = $1
+AND rechirp_of = $2;
+
+-- name: UpdateChirpBody :one
+UPDATE chirps
+SET body = @body,
+    held_at = COALESCE(held_at, sqlc.narg(held_at)),
+    updated_at = now(),
+    edited_at = now()
+WHERE id = @id
+RETURNING *;
+
+-- name: GetDeletedChirps :many
+SELECT * FROM chirps
+WHERE user_id = @user_id
+AND deleted_at > @cutoff::timestamp
+AND (expires_at IS NULL OR expires_at > now())
+ORDER BY deleted_at DESC;
+
+-- name: RestoreChirp :one
+UPDATE chirps
+SET deleted_at = NULL
+WHERE id = @id
+AND user_id = @user_id
+AND deleted_at > @cutoff::timestamp
+AND (expires_at IS NULL OR expires_at > now())
+RETURNING *;
+
+-- name: PurgeDeletedChirps :execrows
+DELETE FROM chirps
+WHERE id IN (
+    SELECT id FROM chirps
+    WHERE deleted_at < @cutoff::timestamp
+    LIMIT @batch_size
+    FOR UPDATE SKIP LOCKED
+);
+
+-- name: PurgeChirpsOfDeletedUsers :execrows
+DELETE FROM chirps
+WHERE id IN (
+    SELECT chirps.id FROM chirps
+    INNER JOIN users
+    ON users.id = chirps.user_id
+    WHERE users.deleted_at < @cutoff::timestamp
+    LIMIT @batch_size
+    FOR UPDATE OF chirps SKIP LOCKED
+);
+
+-- name: DeleteExpiredChirps :execrows
+DELETE FROM chirps
+WHERE id IN (
+    SELECT id FROM chirps
+    WHERE expires_at <= now()
+    LIMIT @batch_size
+    FOR UPDATE SKIP LOCKED
+);
+
+-- name: HideChirp :one
+UPDATE chirps
+SET hidden_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: CountRecentChirps :one
+SELECT count(*) FROM chirps
+WHERE user_id = @user_id
+AND created_at > @since::timestamp
+AND id IS DISTINCT FROM sqlc.narg(exclude_id)::uuid;
+
+-- name: GetHeldChirps :many
+SELECT * FROM chirps
+WHERE held_at IS NOT NULL
+AND (held_at, id) < (@before::timestamp, @before_id::uuid)
+AND deleted_at IS NULL
+AND hidden_at IS NULL
+AND (expires_at IS NULL OR expires_at > now())
+ORDER BY held_at DESC, id DESC
+LIMIT @page_size;
+
+-- name: ReleaseHeldChirp :one
+UPDATE chirps
+SET held_at = NULL
+WHERE id = $1
+AND held_at IS NOT NULL
+AND deleted_at IS NULL
+RETURNING *;
+
+-- name: RejectHeldChirp :one
+UPDATE chirps
+SET held_at = NULL,
+    hidden_at = now()
+WHERE id = $1
+AND held_at IS NOT NULL
+AND deleted_at IS NULL
+RETURNING *;
+
+-- name: UpdateChirpSensitivity :one
+UPDATE chirps
+SET content_warning = $1,
+    sensitive = $2
+WHERE id = $3
+AND deleted_at IS NULL
+RETURNING *;
+
+-- name: GetPinnedChirps :many
+SELECT chirps.* FROM chirps
+WHERE chirps.user_id = @author_id
+AND chirps.pinned_at IS NOT NULL
+AND chirp_live(chirps)
+AND chirp_readable(chirps, @viewer_id)
+AND NOT (@hide_sensitive::bool AND chirp_sensitive(chirps))
+ORDER BY chirps.pinned_at DESC;
+
+-- name: GetChirpsByAuthor :many
+SELECT chirps.* FROM chirps
+WHERE chirps.user_id = @author_id
+AND chirps.pinned_at IS NULL
+AND chirp_live(chirps)
+AND chirp_readable(chirps, @viewer_id)
+AND NOT (@hide_sensitive::bool AND chirp_sensitive(chirps))
+ORDER BY chirps.created_at ASC;
+
+-- name: CountPinnedChirps :one
+SELECT count(*) FROM chirps
+WHERE user_id = $1
+AND pinned_at IS NOT NULL
+AND chirp_live(chirps);
+
+-- name: PinChirp :exec
+UPDATE chirps
+SET pinned_at = now()
+WHERE id = $1;
+
+-- name: UnpinChirp :execrows
+UPDATE chirps
+SET pinned_at = NULL
+WHERE id = $1
+AND user_id = $2
+AND pinned_at IS NOT NULL;
+
+-- name: GetScheduledChirps :many
+SELECT * FROM chirps
+WHERE user_id = $1
+AND scheduled_for IS NOT NULL
+AND deleted_at IS NULL
+ORDER BY scheduled_for ASC;
+
+-- name: GetScheduledChirp :one
+SELECT * FROM chirps
+WHERE id = $1
+AND user_id = $2
+AND scheduled_for IS NOT NULL
+AND deleted_at IS NULL
+FOR UPDATE;
+
+-- name: UpdateScheduledChirp :one
+UPDATE chirps
+SET body = @body,
+    scheduled_for = @scheduled_for,
+    expires_at = @expires_at,
+    held_at = COALESCE(held_at, sqlc.narg(held_at)),
+    updated_at = now()
+WHERE id = @id
+AND user_id = @user_id
+AND scheduled_for IS NOT NULL
+RETURNING *;
+
+-- name: DeleteScheduledChirp :execrows
+DELETE FROM chirps
+WHERE id = $1
+AND user_id = $2
+AND scheduled_for IS NOT NULL;
+
+-- name: PublishDueChirps :many
+UPDATE chirps
+SET created_at = scheduled_for,
+    updated_at = now(),
+    scheduled_for = NULL
+WHERE id IN (
+    SELECT id FROM chirps
+    WHERE scheduled_for <= now()
+    AND held_at IS NULL
+    AND deleted_at IS NULL
+    ORDER BY scheduled_for
+    LIMIT $1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
@@ -0,0 +1,44 @@
+-- name: CreateDraft :one
+INSERT INTO drafts (user_id, body, quote_of, content_warning, sensitive, visibility)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6
+)
+RETURNING *;
+
+-- name: GetDrafts :many
+SELECT * FROM drafts
+WHERE user_id = $1
+ORDER BY updated_at DESC;
+
+-- name: GetDraft :one
+SELECT * FROM drafts
+WHERE id = $1
+AND user_id = $2;
+
+-- name: UpdateDraft :one
+UPDATE drafts
+SET body = $1,
+    quote_of = $2,
+    content_warning = $3,
+    sensitive = $4,
+    visibility = $5,
+    updated_at = now()
+WHERE id = $6
+AND user_id = $7
+RETURNING *;
+
+-- name: DeleteDraft :execrows
+DELETE FROM drafts
+WHERE id = $1
+AND user_id = $2;
+
+-- name: ClaimDraft :one
+DELETE FROM drafts
+WHERE id = $1
+AND user_id = $2
+RETURNING *;
@@ -0,0 +1,19 @@
+-- name: FollowUser :execrows
+INSERT INTO follows (follower_id, followee_id)
+VALUES (
+    $1,
+    $2
+)
+ON CONFLICT DO NOTHING;
+
+-- name: UnfollowUser :execrows
+DELETE FROM follows
+WHERE follower_id = $1
+AND followee_id = $2;
+
+-- name: IsFollowing :one
+SELECT EXISTS (
+    SELECT 1 FROM follows
+    WHERE follower_id = $1
+    AND followee_id = $2
+);
@@ -0,0 +1,48 @@
+-- name: UpsertHashtag :one
+INSERT INTO hashtags (tag)
+VALUES (
+    $1
+)
+ON CONFLICT (tag) DO UPDATE SET tag = EXCLUDED.tag
+RETURNING *;
+
+-- name: AddChirpHashtag :exec
+INSERT INTO chirp_hashtags (chirp_id, hashtag_id)
+VALUES (
+    $1,
+    $2
+)
+ON CONFLICT DO NOTHING;
+
+-- name: GetChirpsByHashtag :many
+SELECT chirps.* FROM chirps
+INNER JOIN chirp_hashtags
+ON chirps.id = chirp_hashtags.chirp_id
+INNER JOIN hashtags
+ON hashtags.id = chirp_hashtags.hashtag_id
+WHERE hashtags.tag = @tag
+AND chirp_live(chirps)
+AND chirps.visibility = 'public'
+AND NOT (@hide_sensitive::bool AND chirp_sensitive(chirps))
+ORDER BY chirps.created_at DESC;
+
+-- name: GetHashtagUsage :many
+SELECT hashtags.tag,
+    date_trunc('hour', chirp_hashtags.created_at)::timestamp AS bucket,
+    count(*) AS uses
+FROM chirp_hashtags
+INNER JOIN hashtags
+ON hashtags.id = chirp_hashtags.hashtag_id
+INNER JOIN chirps
+ON chirps.id = chirp_hashtags.chirp_id
+WHERE chirp_hashtags.created_at >= @since::timestamp
+AND chirp_live(chirps)
+AND chirps.visibility = 'public'
+GROUP BY hashtags.tag, bucket;
+
+-- name: DeleteStaleChirpHashtags :exec
+DELETE FROM chirp_hashtags
+USING hashtags
+WHERE chirp_hashtags.chirp_id = @chirp_id
+AND hashtags.id = chirp_hashtags.hashtag_id
+AND NOT hashtags.tag = ANY(@tags::text[]);
@@ -0,0 +1,48 @@
+-- name: CreateLink :one
+INSERT INTO links (code, url)
+VALUES (
+    $1,
+    $2
+)
+ON CONFLICT DO NOTHING
+RETURNING *;
+
+-- name: GetLinkByURL :one
+SELECT * FROM links
+WHERE url = $1;
+
+-- name: GetLinksByURLs :many
+SELECT * FROM links
+WHERE url = ANY(@urls::text[]);
+
+-- name: FollowLink :one
+UPDATE links
+SET clicks = clicks + 1
+WHERE code = $1
+RETURNING url;
+
+-- name: GetLinksToPreview :many
+SELECT links.* FROM links
+LEFT JOIN link_previews ON link_previews.link_id = links.id
+WHERE link_previews.link_id IS NULL
+   OR link_previews.fetched_at < @stale_before
+ORDER BY link_previews.fetched_at NULLS FIRST, links.created_at
+LIMIT @batch_size;
+
+-- name: SaveLinkPreview :exec
+INSERT INTO link_previews (link_id, title, description, image_url)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4
+)
+ON CONFLICT (link_id) DO UPDATE
+SET title = EXCLUDED.title,
+    description = EXCLUDED.description,
+    image_url = EXCLUDED.image_url,
+    fetched_at = now();
+
+-- name: GetLinkPreviews :many
+SELECT * FROM link_previews
+WHERE link_id = ANY(@link_ids::uuid[]);
@@ -0,0 +1,73 @@
+-- name: CreateMediaFile :one
+INSERT INTO media_files (user_id, storage_key, content_type, width, height, size)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6
+)
+RETURNING *;
+
+-- name: GetUserMediaFiles :many
+SELECT * FROM media_files
+WHERE id = ANY(@ids::uuid[])
+AND user_id = @user_id;
+
+-- name: CreateChirpAttachment :exec
+INSERT INTO chirp_attachments (chirp_id, media_id, position, alt_text)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4
+);
+
+-- name: GetChirpAttachments :many
+SELECT chirp_attachments.chirp_id, chirp_attachments.position, chirp_attachments.alt_text, media_files.id, media_files.content_type, media_files.width, media_files.height FROM chirp_attachments
+INNER JOIN media_files
+ON media_files.id = chirp_attachments.media_id
+WHERE chirp_attachments.chirp_id = ANY(@chirp_ids::uuid[])
+ORDER BY chirp_attachments.chirp_id, chirp_attachments.position;
+
+-- name: GetMediaFileAccess :one
+SELECT sqlc.embed(media_files),
+    EXISTS (
+        SELECT 1 FROM chirp_attachments
+        INNER JOIN chirps
+        ON chirps.id = chirp_attachments.chirp_id
+        WHERE chirp_attachments.media_id = media_files.id
+        AND chirp_live(chirps)
+        AND chirp_readable(chirps, @viewer_id)
+    ) AS readable,
+    EXISTS (
+        SELECT 1 FROM chirp_attachments
+        INNER JOIN chirps
+        ON chirps.id = chirp_attachments.chirp_id
+        WHERE chirp_attachments.media_id = media_files.id
+        AND chirp_live(chirps)
+        AND chirps.visibility IN ('public', 'unlisted')
+    ) AS public
+FROM media_files
+WHERE media_files.id = @id;
+
+-- name: DeleteOrphanedMediaFiles :many
+DELETE FROM media_files
+WHERE id IN (
+    SELECT orphan.id FROM media_files AS orphan
+    WHERE orphan.created_at < @cutoff::timestamp
+    AND NOT EXISTS (
+        SELECT 1 FROM chirp_attachments
+        WHERE chirp_attachments.media_id = orphan.id
+    )
+    LIMIT @batch_size
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING storage_key;
+
+-- name: MediaKeyInUse :one
+SELECT EXISTS (
+    SELECT 1 FROM media_files
+    WHERE storage_key = $1
+);
@@ -0,0 +1,29 @@
+-- name: AddChirpMention :exec
+INSERT INTO chirp_mentions (chirp_id, user_id, start_offset, end_offset)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4
+);
+
+-- name: GetChirpMentions :many
+SELECT * FROM chirp_mentions
+WHERE chirp_id = ANY(@chirp_ids::uuid[])
+ORDER BY chirp_id, start_offset;
+
+-- name: GetChirpsMentioningUser :many
+SELECT chirps.* FROM chirps
+WHERE EXISTS (
+    SELECT 1 FROM chirp_mentions
+    WHERE chirp_mentions.chirp_id = chirps.id
+    AND chirp_mentions.user_id = @user_id
+)
+AND chirp_live(chirps)
+AND chirp_readable(chirps, @user_id)
+AND NOT (@hide_sensitive::bool AND chirp_sensitive(chirps))
+ORDER BY chirps.created_at DESC;
+
+-- name: DeleteChirpMentions :exec
+DELETE FROM chirp_mentions
+WHERE chirp_id = $1;
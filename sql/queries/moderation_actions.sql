@@ -0,0 +1,16 @@
+-- name: CreateModerationAction :one
+INSERT INTO moderation_actions (moderator_id, action, report_id, chirp_id, user_id, note)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6
+)
+RETURNING *;
+
+-- name: GetModerationActionsByReport :many
+SELECT * FROM moderation_actions
+WHERE report_id = $1
+ORDER BY created_at ASC;
@@ -0,0 +1,30 @@
+-- name: CreateNotification :one
+INSERT INTO notifications (user_id, kind, actor_id, chirp_id, data)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5
+)
+RETURNING *;
+
+-- name: GetNotifications :many
+SELECT * FROM notifications
+WHERE user_id = @user_id
+AND (created_at, id) < (@before::timestamp, @before_id::uuid)
+AND (NOT @unread_only::bool OR read_at IS NULL)
+ORDER BY created_at DESC, id DESC
+LIMIT @page_size;
+
+-- name: CountUnreadNotifications :one
+SELECT count(*) FROM notifications
+WHERE user_id = $1
+AND read_at IS NULL;
+
+-- name: MarkNotificationsRead :execrows
+UPDATE notifications
+SET read_at = now()
+WHERE user_id = @user_id
+AND read_at IS NULL
+AND (@mark_all::bool OR id = ANY(@ids::uuid[]));
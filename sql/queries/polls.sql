@@ -0,0 +1,62 @@
+-- name: CreatePoll :exec
+INSERT INTO polls (chirp_id, closes_at, allow_vote_change, results_visibility)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4
+);
+
+-- name: CreatePollOption :exec
+INSERT INTO poll_options (chirp_id, position, text)
+VALUES (
+    $1,
+    $2,
+    $3
+);
+
+-- name: GetPoll :one
+SELECT * FROM polls
+WHERE chirp_id = $1;
+
+-- name: GetPolls :many
+SELECT * FROM polls
+WHERE chirp_id = ANY(@chirp_ids::uuid[]);
+
+-- name: GetPollOptions :many
+SELECT poll_options.*, count(poll_votes.user_id) AS votes FROM poll_options
+LEFT JOIN poll_votes
+ON poll_votes.option_id = poll_options.id
+WHERE poll_options.chirp_id = ANY(@chirp_ids::uuid[])
+GROUP BY poll_options.id
+ORDER BY poll_options.chirp_id, poll_options.position;
+
+-- name: GetPollVotes :many
+SELECT chirp_id, option_id FROM poll_votes
+WHERE user_id = @user_id
+AND chirp_id = ANY(@chirp_ids::uuid[]);
+
+-- name: CastPollVote :execrows
+INSERT INTO poll_votes (chirp_id, user_id, option_id)
+VALUES (
+    $1,
+    $2,
+    $3
+)
+ON CONFLICT (chirp_id, user_id) DO NOTHING;
+
+-- name: ChangePollVote :exec
+INSERT INTO poll_votes (chirp_id, user_id, option_id)
+VALUES (
+    $1,
+    $2,
+    $3
+)
+ON CONFLICT (chirp_id, user_id) DO UPDATE
+SET option_id = EXCLUDED.option_id,
+    updated_at = now();
+
+-- name: ShiftPollClose :exec
+UPDATE polls
+SET closes_at = closes_at + make_interval(secs => @shift_seconds::float8)
+WHERE chirp_id = @chirp_id;
@@ -20,4 +20,14 @@ INNER JOIN refresh_tokens
 ON users.id = refresh_tokens.user_id
 WHERE refresh_tokens.token = $1
 AND refresh_tokens.revoked_at IS NULL
-AND refresh_tokens.expires_at >= now();
+AND refresh_tokens.expires_at >= now()
+AND users.deleted_at IS NULL
+AND users.banned_at IS NULL
+AND (users.suspended_until IS NULL OR users.suspended_until <= now());
+
+-- name: RevokeUserRefreshTokens :exec
+UPDATE refresh_tokens
+SET revoked_at = now(),
+    updated_at = now()
+WHERE user_id = $1
+AND revoked_at IS NULL;
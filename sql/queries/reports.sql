@@ -0,0 +1,74 @@
+-- name: CreateChirpReport :one
+INSERT INTO reports (reporter_id, chirp_id, reported_user_id, reason, details, about_chirp)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    true
+)
+ON CONFLICT (reporter_id, chirp_id) WHERE chirp_id IS NOT NULL AND status = 'open'
+DO UPDATE SET reason = EXCLUDED.reason,
+    details = EXCLUDED.details,
+    updated_at = now()
+RETURNING *;
+
+-- name: CreateUserReport :one
+INSERT INTO reports (reporter_id, reported_user_id, reason, details)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4
+)
+ON CONFLICT (reporter_id, reported_user_id) WHERE NOT about_chirp AND status = 'open'
+DO UPDATE SET reason = EXCLUDED.reason,
+    details = EXCLUDED.details,
+    updated_at = now()
+RETURNING *;
+
+-- name: GetReport :one
+SELECT * FROM reports
+WHERE id = $1;
+
+-- name: GetReports :many
+SELECT * FROM reports
+WHERE status = @status
+AND (sqlc.narg(reason)::text IS NULL OR reason = sqlc.narg(reason))
+AND (sqlc.narg(assigned_to)::uuid IS NULL OR assigned_to = sqlc.narg(assigned_to))
+AND (NOT @unassigned::bool OR assigned_to IS NULL)
+AND (created_at, id) < (@before::timestamp, @before_id::uuid)
+ORDER BY created_at DESC, id DESC
+LIMIT @page_size;
+
+-- name: AssignReport :one
+UPDATE reports
+SET assigned_to = $1,
+    updated_at = now()
+WHERE id = $2
+AND status = 'open'
+RETURNING *;
+
+-- name: ResolveReport :one
+UPDATE reports
+SET status = 'resolved',
+    resolution = $1,
+    resolved_by = $2,
+    resolved_at = now(),
+    updated_at = now()
+WHERE id = $3
+AND status = 'open'
+RETURNING *;
+
+-- name: ResolveDuplicateReports :exec
+UPDATE reports
+SET status = 'resolved',
+    resolution = @resolution,
+    resolved_by = @resolved_by,
+    resolved_at = now(),
+    updated_at = now()
+WHERE status = 'open'
+AND id <> @id
+AND about_chirp = @about_chirp
+AND (chirp_id = sqlc.narg(chirp_id) OR (NOT about_chirp AND reported_user_id = @reported_user_id));
@@ -0,0 +1,55 @@
+-- name: SearchChirps :many
+SELECT sqlc.embed(chirps), (CASE
+    WHEN @query::text = '' THEN translate(chirps.body, E'\x02\x03', '')
+    ELSE ts_headline('english', translate(chirps.body, E'\x02\x03', ''), to_tsquery('english', @query::text),
+        E'StartSel=\x02, StopSel=\x03, MinWords=15, MaxWords=35')
+END)::text AS snippet
+FROM chirps
+INNER JOIN chirp_search
+ON chirp_search.chirp_id = chirps.id
+INNER JOIN users
+ON users.id = chirps.user_id
+WHERE (@query::text = '' OR chirp_search.document @@ to_tsquery('english', @query::text))
+AND (@from_handle::text = '' OR lower(users.handle) = lower(@from_handle::text))
+AND NOT EXISTS (
+    SELECT 1 FROM unnest(@tags::text[]) AS wanted(tag)
+    WHERE NOT EXISTS (
+        SELECT 1 FROM chirp_hashtags
+        INNER JOIN hashtags
+        ON hashtags.id = chirp_hashtags.hashtag_id
+        WHERE chirp_hashtags.chirp_id = chirps.id
+        AND hashtags.tag = wanted.tag
+    )
+)
+AND chirps.rechirp_of IS NULL
+AND chirp_live(chirps)
+AND (chirps.visibility = 'public'
+    OR chirps.user_id = @viewer_id
+    OR (chirps.visibility = 'followers' AND EXISTS (
+        SELECT 1 FROM follows
+        WHERE follows.follower_id = @viewer_id
+        AND follows.followee_id = chirps.user_id
+    )))
+AND NOT (@hide_sensitive::bool AND chirp_sensitive(chirps))
+ORDER BY (CASE
+    WHEN @query::text = '' THEN 1
+    ELSE ts_rank_cd(chirp_search.document, to_tsquery('english', @query::text))
+END) * (1 + power(0.5::float8, EXTRACT(EPOCH FROM now() - chirps.created_at)::float8 / @half_life_seconds::float8)) DESC,
+    chirps.created_at DESC,
+    chirps.id
+LIMIT @page_size
+OFFSET @page_offset;
+
+-- name: SearchUsers :many
+SELECT users.id, users.handle, users.avatar FROM users
+INNER JOIN user_search
+ON user_search.user_id = users.id
+WHERE user_search.document @@ to_tsquery('simple', @query::text)
+AND users.handle IS NOT NULL
+AND users.deleted_at IS NULL
+AND users.banned_at IS NULL
+AND (users.suspended_until IS NULL OR users.suspended_until <= now())
+ORDER BY ts_rank(user_search.document, to_tsquery('simple', @query::text)) DESC,
+    users.handle
+LIMIT @page_size
+OFFSET @page_offset;
@@ -1,8 +1,9 @@
 -- name: CreateUser :one
-INSERT INTO users (email, hashed_password)
+INSERT INTO users (email, hashed_password, handle)
 VALUES (
     $1,
-    $2
+    $2,
+    $3
 )
 RETURNING *;
 
@@ -10,4 +11,112 @@ RETURNING *;
 DELETE FROM users;
 
 -- name: GetUserByEmail :one
-SELECT * FROM users WHERE email = $1;
\ No newline at end of file
+SELECT * FROM users
+WHERE email = $1
+AND deleted_at IS NULL;
+
+-- name: GetUser :one
+SELECT * FROM users
+WHERE id = $1
+AND deleted_at IS NULL;
+
+-- name: GetUsersByHandles :many
+SELECT id, handle FROM users
+WHERE handle = ANY(@handles::text[])
+AND deleted_at IS NULL;
+
+-- name: UpdateUserHandle :one
+UPDATE users
+SET handle = $1,
+    updated_at = now()
+WHERE id = $2
+RETURNING *;
+
+-- name: SoftDeleteUser :exec
+UPDATE users
+SET deleted_at = now(),
+    updated_at = now()
+WHERE id = $1;
+
+-- name: GetDeletedUserByEmail :one
+SELECT * FROM users
+WHERE email = @email
+AND deleted_at > @cutoff::timestamp;
+
+-- name: RestoreUser :one
+UPDATE users
+SET deleted_at = NULL,
+    updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: PurgeDeletedUsers :execrows
+DELETE FROM users
+WHERE id IN (
+    SELECT id FROM users
+    WHERE deleted_at < @cutoff::timestamp
+    AND NOT EXISTS (
+        SELECT 1 FROM chirps
+        WHERE chirps.user_id = users.id
+    )
+    LIMIT @batch_size
+    FOR UPDATE SKIP LOCKED
+);
+
+-- name: GetUserRole :one
+SELECT role FROM users
+WHERE id = $1
+AND deleted_at IS NULL;
+
+-- name: GetUserTier :one
+SELECT tier FROM users
+WHERE id = $1;
+
+-- name: SuspendUser :execrows
+UPDATE users
+SET suspended_until = $1,
+    updated_at = now()
+WHERE id = $2
+AND deleted_at IS NULL;
+
+-- name: BanUser :execrows
+UPDATE users
+SET banned_at = now(),
+    updated_at = now()
+WHERE id = $1
+AND deleted_at IS NULL;
+
+-- name: UnbanUser :execrows
+UPDATE users
+SET banned_at = NULL,
+    updated_at = now()
+WHERE id = $1
+AND deleted_at IS NULL;
+
+-- name: GetUserAccess :one
+SELECT banned_at, suspended_until FROM users
+WHERE id = $1
+AND deleted_at IS NULL;
+
+-- name: UpdateUserPreferences :one
+UPDATE users
+SET expand_sensitive = $1,
+    updated_at = now()
+WHERE id = $2
+RETURNING *;
+
+-- name: LockUser :exec
+SELECT id FROM users
+WHERE id = $1
+FOR UPDATE;
+
+-- name: UpdateUserAvatar :one
+UPDATE users
+SET avatar = $1,
+    updated_at = now()
+WHERE id = $2
+RETURNING *;
+
+-- name: GetExpandSensitive :one
+SELECT expand_sensitive FROM users
+WHERE id = $1;
@@ -0,0 +1,21 @@
+-- +goose Up
+-- Rechirps carry no body of their own, so bodies can no longer be unique.
+ALTER TABLE chirps
+DROP CONSTRAINT chirps_body_key;
+
+ALTER TABLE chirps
+ADD COLUMN rechirp_of UUID REFERENCES chirps (id) ON DELETE CASCADE,
+ADD COLUMN quote_of UUID REFERENCES chirps (id) ON DELETE SET NULL;
+
+CREATE UNIQUE INDEX chirps_user_rechirp_idx ON chirps (user_id, rechirp_of)
+WHERE rechirp_of IS NOT NULL;
+
+-- +goose Down
+DROP INDEX chirps_user_rechirp_idx;
+
+ALTER TABLE chirps
+DROP COLUMN quote_of,
+DROP COLUMN rechirp_of;
+
+ALTER TABLE chirps
+ADD CONSTRAINT chirps_body_key UNIQUE (body);
@@ -0,0 +1,18 @@
+-- +goose Up
+-- kind is plain text rather than an enum so new event kinds need no migration.
+CREATE TABLE notifications(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    kind TEXT NOT NULL,
+    actor_id UUID REFERENCES users (id) ON DELETE SET NULL,
+    chirp_id UUID REFERENCES chirps (id) ON DELETE SET NULL,
+    data JSONB NOT NULL DEFAULT '{}',
+    read_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX notifications_user_id_created_at_idx ON notifications (user_id, created_at DESC);
+CREATE INDEX notifications_unread_idx ON notifications (user_id) WHERE read_at IS NULL;
+
+-- +goose Down
+DROP TABLE notifications;
@@ -0,0 +1,18 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN role TEXT NOT NULL DEFAULT 'user' CHECK (role IN ('user', 'moderator', 'admin'));
+
+CREATE TABLE banned_words(
+    word TEXT PRIMARY KEY,
+    created_by UUID REFERENCES users (id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+INSERT INTO banned_words (word)
+VALUES ('kerfuffle'), ('sharbert'), ('fornax');
+
+-- +goose Down
+DROP TABLE banned_words;
+
+ALTER TABLE users
+DROP COLUMN role;
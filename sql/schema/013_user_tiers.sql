@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN tier TEXT NOT NULL DEFAULT 'standard';
+
+-- +goose Down
+ALTER TABLE users
+DROP COLUMN tier;
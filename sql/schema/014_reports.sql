@@ -0,0 +1,53 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN hidden_at TIMESTAMP;
+
+ALTER TABLE users
+ADD COLUMN suspended_until TIMESTAMP;
+
+-- A report is about a chirp (chirp_id set) or directly about a user. Either
+-- way reported_user_id holds the account responsible.
+CREATE TABLE reports(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    reporter_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    chirp_id UUID REFERENCES chirps (id) ON DELETE CASCADE,
+    reported_user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    reason TEXT NOT NULL CHECK (reason IN ('spam', 'harassment', 'hate', 'violence', 'sexual', 'self_harm', 'misinformation', 'other')),
+    details TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'resolved')),
+    assigned_to UUID REFERENCES users (id) ON DELETE SET NULL,
+    resolution TEXT CHECK (resolution IN ('dismiss', 'hide_chirp', 'suspend_author')),
+    resolved_by UUID REFERENCES users (id) ON DELETE SET NULL,
+    resolved_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    updated_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX reports_reporter_chirp_idx ON reports (reporter_id, chirp_id)
+WHERE chirp_id IS NOT NULL;
+CREATE UNIQUE INDEX reports_reporter_user_idx ON reports (reporter_id, reported_user_id)
+WHERE chirp_id IS NULL;
+CREATE INDEX reports_status_created_at_idx ON reports (status, created_at DESC);
+
+CREATE TABLE moderation_actions(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    moderator_id UUID REFERENCES users (id) ON DELETE SET NULL,
+    action TEXT NOT NULL,
+    report_id UUID REFERENCES reports (id) ON DELETE SET NULL,
+    chirp_id UUID REFERENCES chirps (id) ON DELETE SET NULL,
+    user_id UUID REFERENCES users (id) ON DELETE SET NULL,
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX moderation_actions_report_id_idx ON moderation_actions (report_id);
+
+-- +goose Down
+DROP TABLE moderation_actions;
+DROP TABLE reports;
+
+ALTER TABLE users
+DROP COLUMN suspended_until;
+
+ALTER TABLE chirps
+DROP COLUMN hidden_at;
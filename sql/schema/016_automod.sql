@@ -0,0 +1,31 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN held_at TIMESTAMP;
+
+CREATE INDEX chirps_held_at_idx ON chirps (held_at) WHERE held_at IS NOT NULL;
+CREATE INDEX chirps_user_id_created_at_idx ON chirps (user_id, created_at DESC);
+
+-- One row per rule that did not allow a chirp. Rejected chirps are never
+-- written, so chirp_id is NULL for them and body keeps what was submitted.
+CREATE TABLE automod_results(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    chirp_id UUID REFERENCES chirps (id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    rule TEXT NOT NULL,
+    verdict TEXT NOT NULL CHECK (verdict IN ('flag', 'reject')),
+    reason TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX automod_results_chirp_id_idx ON automod_results (chirp_id);
+CREATE INDEX automod_results_user_id_created_at_idx ON automod_results (user_id, created_at DESC);
+
+-- +goose Down
+DROP TABLE automod_results;
+
+DROP INDEX chirps_user_id_created_at_idx;
+DROP INDEX chirps_held_at_idx;
+
+ALTER TABLE chirps
+DROP COLUMN held_at;
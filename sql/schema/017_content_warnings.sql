@@ -0,0 +1,15 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN content_warning TEXT,
+ADD COLUMN sensitive BOOLEAN NOT NULL DEFAULT false;
+
+ALTER TABLE users
+ADD COLUMN expand_sensitive BOOLEAN NOT NULL DEFAULT false;
+
+-- +goose Down
+ALTER TABLE users
+DROP COLUMN expand_sensitive;
+
+ALTER TABLE chirps
+DROP COLUMN sensitive,
+DROP COLUMN content_warning;
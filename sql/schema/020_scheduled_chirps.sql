@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN scheduled_for TIMESTAMP;
+
+CREATE INDEX chirps_scheduled_for_idx ON chirps (scheduled_for) WHERE scheduled_for IS NOT NULL;
+
+-- +goose Down
+DROP INDEX chirps_scheduled_for_idx;
+
+ALTER TABLE chirps
+DROP COLUMN scheduled_for;
@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE drafts(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    quote_of UUID REFERENCES chirps (id) ON DELETE SET NULL,
+    content_warning TEXT,
+    sensitive BOOLEAN NOT NULL DEFAULT false,
+    visibility TEXT NOT NULL DEFAULT 'public' CHECK (visibility IN ('public', 'followers', 'unlisted', 'private')),
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    updated_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX drafts_user_id_updated_at_idx ON drafts (user_id, updated_at DESC);
+
+-- +goose Down
+DROP TABLE drafts;
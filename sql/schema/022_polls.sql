@@ -0,0 +1,38 @@
+-- +goose Up
+CREATE TABLE polls(
+    chirp_id UUID PRIMARY KEY REFERENCES chirps (id) ON DELETE CASCADE,
+    closes_at TIMESTAMP NOT NULL,
+    allow_vote_change BOOLEAN NOT NULL DEFAULT false,
+    results_visibility TEXT NOT NULL DEFAULT 'after_vote' CHECK (results_visibility IN ('after_vote', 'after_close')),
+    created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE TABLE poll_options(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    chirp_id UUID NOT NULL REFERENCES polls (chirp_id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    text TEXT NOT NULL,
+    UNIQUE (chirp_id, position),
+    UNIQUE (chirp_id, id)
+);
+
+-- Votes are never counted into a column: totals are computed from this
+-- table, whose primary key allows one vote per user and poll, so they are
+-- always consistent. The composite foreign key keeps votes on the poll they
+-- were cast in.
+CREATE TABLE poll_votes(
+    chirp_id UUID NOT NULL REFERENCES polls (chirp_id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    option_id UUID NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    updated_at TIMESTAMP NOT NULL DEFAULT now(),
+    PRIMARY KEY (chirp_id, user_id),
+    FOREIGN KEY (chirp_id, option_id) REFERENCES poll_options (chirp_id, id) ON DELETE CASCADE
+);
+
+CREATE INDEX poll_votes_option_id_idx ON poll_votes (option_id);
+
+-- +goose Down
+DROP TABLE poll_votes;
+DROP TABLE poll_options;
+DROP TABLE polls;
@@ -0,0 +1,26 @@
+-- +goose Up
+CREATE TABLE media_files(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    storage_key TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    width INTEGER NOT NULL,
+    height INTEGER NOT NULL,
+    size INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX media_files_user_id_idx ON media_files (user_id);
+
+CREATE TABLE chirp_attachments(
+    chirp_id UUID NOT NULL REFERENCES chirps (id) ON DELETE CASCADE,
+    media_id UUID NOT NULL REFERENCES media_files (id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    alt_text TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (chirp_id, position),
+    UNIQUE (chirp_id, media_id)
+);
+
+-- +goose Down
+DROP TABLE chirp_attachments;
+DROP TABLE media_files;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- avatar is the content hash and extension of the user's avatar, e.g.
+-- "<sha256>.png"; each size is stored as "<sha256>-<size>.png".
+ALTER TABLE users
+ADD COLUMN avatar TEXT;
+
+-- +goose Down
+ALTER TABLE users
+DROP COLUMN avatar;
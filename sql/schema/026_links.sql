@@ -0,0 +1,11 @@
+-- +goose Up
+CREATE TABLE links(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code TEXT NOT NULL UNIQUE,
+    url TEXT NOT NULL UNIQUE,
+    clicks BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+-- +goose Down
+DROP TABLE links;
@@ -0,0 +1,11 @@
+-- +goose Up
+CREATE TABLE link_previews(
+    link_id UUID PRIMARY KEY REFERENCES links(id) ON DELETE CASCADE,
+    title TEXT NOT NULL,
+    description TEXT NOT NULL,
+    image_url TEXT NOT NULL,
+    fetched_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+-- +goose Down
+DROP TABLE link_previews;
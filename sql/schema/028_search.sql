@@ -0,0 +1,79 @@
+-- +goose Up
+-- Search documents live in their own tables, kept up to date by triggers, so
+-- the chirps and users rows the rest of the application reads stay as they
+-- are.
+CREATE TABLE chirp_search(
+    chirp_id UUID PRIMARY KEY REFERENCES chirps(id) ON DELETE CASCADE,
+    document TSVECTOR NOT NULL
+);
+
+CREATE INDEX chirp_search_document_idx ON chirp_search USING GIN (document);
+
+CREATE TABLE user_search(
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    document TSVECTOR NOT NULL
+);
+
+CREATE INDEX user_search_document_idx ON user_search USING GIN (document);
+
+-- +goose StatementBegin
+CREATE FUNCTION chirp_search_document(body TEXT, content_warning TEXT) RETURNS TSVECTOR AS $$
+    SELECT setweight(to_tsvector('english', body), 'A')
+        || setweight(to_tsvector('english', coalesce(content_warning, '')), 'B');
+$$ LANGUAGE SQL IMMUTABLE;
+-- +goose StatementEnd
+
+-- Handles are also indexed split on underscores, so "jane" finds jane_doe.
+-- +goose StatementBegin
+CREATE FUNCTION user_search_document(handle TEXT) RETURNS TSVECTOR AS $$
+    SELECT to_tsvector('simple', coalesce(handle, '') || ' ' || replace(coalesce(handle, ''), '_', ' '));
+$$ LANGUAGE SQL IMMUTABLE;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE FUNCTION index_chirp() RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO chirp_search (chirp_id, document)
+    VALUES (NEW.id, chirp_search_document(NEW.body, NEW.content_warning))
+    ON CONFLICT (chirp_id) DO UPDATE
+    SET document = EXCLUDED.document;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE FUNCTION index_user() RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO user_search (user_id, document)
+    VALUES (NEW.id, user_search_document(NEW.handle))
+    ON CONFLICT (user_id) DO UPDATE
+    SET document = EXCLUDED.document;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER chirps_search_index
+AFTER INSERT OR UPDATE OF body, content_warning ON chirps
+FOR EACH ROW EXECUTE FUNCTION index_chirp();
+
+CREATE TRIGGER users_search_index
+AFTER INSERT OR UPDATE OF handle ON users
+FOR EACH ROW EXECUTE FUNCTION index_user();
+
+INSERT INTO chirp_search (chirp_id, document)
+SELECT id, chirp_search_document(body, content_warning) FROM chirps;
+
+INSERT INTO user_search (user_id, document)
+SELECT id, user_search_document(handle) FROM users;
+
+-- +goose Down
+DROP TRIGGER users_search_index ON users;
+DROP TRIGGER chirps_search_index ON chirps;
+DROP FUNCTION index_user();
+DROP FUNCTION index_chirp();
+DROP FUNCTION user_search_document(TEXT);
+DROP FUNCTION chirp_search_document(TEXT, TEXT);
+DROP TABLE user_search;
+DROP TABLE chirp_search;
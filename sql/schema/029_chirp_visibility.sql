@@ -0,0 +1,68 @@
+-- +goose Up
+-- The conditions under which a chirp can be read, shared by every query that
+-- fetches or lists chirps so that they cannot drift apart.
+
+-- chirp_shown reports whether the chirp itself is out: published, not
+-- deleted, hidden, held or expired, and by an author in good standing.
+-- +goose StatementBegin
+CREATE FUNCTION chirp_shown(c chirps) RETURNS BOOLEAN AS $$
+    SELECT c.deleted_at IS NULL
+        AND c.hidden_at IS NULL
+        AND c.held_at IS NULL
+        AND c.scheduled_for IS NULL
+        AND (c.expires_at IS NULL OR c.expires_at > now())
+        AND EXISTS (
+            SELECT 1 FROM users
+            WHERE users.id = c.user_id
+            AND users.deleted_at IS NULL
+            AND users.banned_at IS NULL
+            AND (users.suspended_until IS NULL OR users.suspended_until <= now())
+        );
+$$ LANGUAGE SQL STABLE;
+-- +goose StatementEnd
+
+-- chirp_live reports whether the chirp is shown and, if it is a rechirp,
+-- whether the chirp it reposts is shown too, so that a rechirp never
+-- outlives its original.
+-- +goose StatementBegin
+CREATE FUNCTION chirp_live(c chirps) RETURNS BOOLEAN AS $$
+    SELECT chirp_shown(c)
+        AND (c.rechirp_of IS NULL OR EXISTS (
+            SELECT 1 FROM chirps AS original
+            WHERE original.id = c.rechirp_of
+            AND chirp_shown(original)
+        ));
+$$ LANGUAGE SQL STABLE;
+-- +goose StatementEnd
+
+-- chirp_readable reports whether the chirp's visibility lets viewer_id read
+-- it. Anonymous viewers pass the nil UUID.
+-- +goose StatementBegin
+CREATE FUNCTION chirp_readable(c chirps, viewer_id UUID) RETURNS BOOLEAN AS $$
+    SELECT c.visibility IN ('public', 'unlisted')
+        OR c.user_id = viewer_id
+        OR (c.visibility = 'followers' AND EXISTS (
+            SELECT 1 FROM follows
+            WHERE follows.follower_id = viewer_id
+            AND follows.followee_id = c.user_id
+        ));
+$$ LANGUAGE SQL STABLE;
+-- +goose StatementEnd
+
+-- chirp_sensitive reports whether the chirp, or the chirp it rechirps or
+-- quotes, is sensitive or carries a content warning.
+-- +goose StatementBegin
+CREATE FUNCTION chirp_sensitive(c chirps) RETURNS BOOLEAN AS $$
+    SELECT EXISTS (
+        SELECT 1 FROM chirps AS flagged
+        WHERE flagged.id IN (c.id, c.rechirp_of, c.quote_of)
+        AND (flagged.sensitive OR flagged.content_warning IS NOT NULL)
+    );
+$$ LANGUAGE SQL STABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION chirp_sensitive(chirps);
+DROP FUNCTION chirp_readable(chirps, UUID);
+DROP FUNCTION chirp_live(chirps);
+DROP FUNCTION chirp_shown(chirps);
@@ -0,0 +1,20 @@
+-- +goose Up
+-- Only one open report per reporter and target: once a report is resolved,
+-- reporting the same chirp or user again opens a new report and leaves the
+-- resolved one as it was.
+DROP INDEX reports_reporter_chirp_idx;
+DROP INDEX reports_reporter_user_idx;
+
+CREATE UNIQUE INDEX reports_reporter_chirp_idx ON reports (reporter_id, chirp_id)
+WHERE chirp_id IS NOT NULL AND status = 'open';
+CREATE UNIQUE INDEX reports_reporter_user_idx ON reports (reporter_id, reported_user_id)
+WHERE chirp_id IS NULL AND status = 'open';
+
+-- +goose Down
+DROP INDEX reports_reporter_chirp_idx;
+DROP INDEX reports_reporter_user_idx;
+
+CREATE UNIQUE INDEX reports_reporter_chirp_idx ON reports (reporter_id, chirp_id)
+WHERE chirp_id IS NOT NULL;
+CREATE UNIQUE INDEX reports_reporter_user_idx ON reports (reporter_id, reported_user_id)
+WHERE chirp_id IS NULL;
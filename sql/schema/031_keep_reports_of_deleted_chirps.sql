@@ -0,0 +1,34 @@
+-- +goose Up
+-- Reports outlive the chirps they are about: when a chirp is purged or
+-- expires, its reports stay in the queue and the history with chirp_id
+-- cleared. about_chirp records what the report was about, so that such a
+-- report is not mistaken for one about the user alone.
+ALTER TABLE reports
+ADD COLUMN about_chirp BOOLEAN NOT NULL DEFAULT false;
+
+UPDATE reports
+SET about_chirp = chirp_id IS NOT NULL;
+
+DROP INDEX reports_reporter_user_idx;
+CREATE UNIQUE INDEX reports_reporter_user_idx ON reports (reporter_id, reported_user_id)
+WHERE NOT about_chirp AND status = 'open';
+
+ALTER TABLE reports
+DROP CONSTRAINT reports_chirp_id_fkey,
+ADD CONSTRAINT reports_chirp_id_fkey FOREIGN KEY (chirp_id) REFERENCES chirps (id) ON DELETE SET NULL;
+
+-- +goose Down
+DELETE FROM reports
+WHERE about_chirp
+AND chirp_id IS NULL;
+
+ALTER TABLE reports
+DROP CONSTRAINT reports_chirp_id_fkey,
+ADD CONSTRAINT reports_chirp_id_fkey FOREIGN KEY (chirp_id) REFERENCES chirps (id) ON DELETE CASCADE;
+
+DROP INDEX reports_reporter_user_idx;
+CREATE UNIQUE INDEX reports_reporter_user_idx ON reports (reporter_id, reported_user_id)
+WHERE chirp_id IS NULL AND status = 'open';
+
+ALTER TABLE reports
+DROP COLUMN about_chirp;